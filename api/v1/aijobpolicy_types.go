@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PolicyMode is how the violations of a policy are handled.
+// +kubebuilder:validation:Enum=Enforce;Audit
+type PolicyMode string
+
+const (
+	// PolicyModeEnforce rejects the AI Jobs violating the policy
+	PolicyModeEnforce PolicyMode = "Enforce"
+	// PolicyModeAudit admits the AI Jobs violating the policy with a warning
+	PolicyModeAudit PolicyMode = "Audit"
+)
+
+// AIJobPolicySpec defines the rules the AI Jobs must follow. Empty rules
+// allow everything.
+type AIJobPolicySpec struct {
+	// Whether violations reject the job or only warn, defaults to Enforce
+	Mode PolicyMode `json:"mode,omitempty"`
+
+	// Prefixes of the images the jobs may run, like a registry host followed
+	// by a slash, or full image references pinned by digest
+	AllowedImages []string `json:"allowedImages,omitempty"`
+
+	// Patterns of the models the jobs may train, like "Qwen/*"
+	AllowedModels []string `json:"allowedModels,omitempty"`
+
+	// Largest number of GPUs a job may request, unlimited when zero
+	MaxGPUs int32 `json:"maxGPUs,omitempty"`
+
+	// Largest disk size in GB a job may request, unlimited when zero
+	MaxDiskSize int32 `json:"maxDiskSize,omitempty"`
+
+	// Storage classes the job volumes may use
+	AllowedStorageClasses []string `json:"allowedStorageClasses,omitempty"`
+
+	// Runtime classes the training pods may use
+	AllowedRuntimeClasses []string `json:"allowedRuntimeClasses,omitempty"`
+
+	// Labels every job must have
+	RequiredLabels []string `json:"requiredLabels,omitempty"`
+
+	// Reject jobs whose security contexts or host network break the
+	// restricted Pod Security Standard, like privileged containers, privilege
+	// escalation, running as root, unconfined seccomp or AppArmor profiles or
+	// capabilities that are not allowed. Running as the root group is
+	// rejected as well. The defaults of the operator always pass.
+	RestrictPrivileges bool `json:"restrictPrivileges,omitempty"`
+
+	// Capabilities the containers may add when privileges are restricted
+	AllowedCapabilities []corev1.Capability `json:"allowedCapabilities,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.spec.mode`
+// +kubebuilder:printcolumn:name="Max GPUs",type=integer,JSONPath=`.spec.maxGPUs`
+
+// AIJobPolicy is the Schema for the aijobpolicies API.
+type AIJobPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AIJobPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AIJobPolicyList contains a list of AIJobPolicy.
+type AIJobPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AIJobPolicy `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.spec.mode`
+// +kubebuilder:printcolumn:name="Max GPUs",type=integer,JSONPath=`.spec.maxGPUs`
+
+// ClusterAIJobPolicy is the Schema for the clusteraijobpolicies API.
+type ClusterAIJobPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AIJobPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterAIJobPolicyList contains a list of ClusterAIJobPolicy.
+type ClusterAIJobPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterAIJobPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AIJobPolicy{}, &AIJobPolicyList{}, &ClusterAIJobPolicy{}, &ClusterAIJobPolicyList{})
+}
@@ -17,17 +17,67 @@ limitations under the License.
 package v1
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
-	jobDefaultImageName        = "silentehrec/torchtune:latest"
 	jobDefaultModelName        = "Qwen/Qwen2.5-0.5B-Instruct"
 	jobDefaultDiskSize         = 50
 	jobDefaultStorageClassName = "local-path"
+
+	jobDefaultTensorBoardLogDir     = "tensorboard"
+	jobDefaultWorkspaceMountPath    = "/workspace"
+	jobDefaultTensorBoardImage      = "tensorflow/tensorflow:2.18.0"
+	jobDefaultTensorBoardTTLSeconds = 3600
+	jobDefaultGGUFScheme            = "q4_k_m"
+	jobDefaultLlamaCppImage         = "ghcr.io/ggml-org/llama.cpp:full"
+	jobDefaultAWSCLIImage           = "amazon/aws-cli:2.22.35"
+	jobDefaultCurlImage             = "curlimages/curl:8.11.1"
+	jobDefaultBusyboxImage          = "busybox:1.37"
+	jobDefaultOCIModelPath          = "/models"
+	jobGCSEndpoint                  = "https://storage.googleapis.com"
+	jobDefaultCABundleKey           = "ca.crt"
+)
+
+// Modules of the model that can receive adapters
+var jobAdapterTargetModules = []string{"q_proj", "k_proj", "v_proj", "output_proj", "mlp", "output"}
+
+// Volumes and mount paths of the pods generated by the operator, which extra
+// volumes cannot replace
+var (
+	jobReservedVolumeNames = []string{"model", "model-source", "scratch", "shm", "ca-bundle"}
+	jobReservedMountPaths  = []string{"/source", "/scratch", "/dev/shm", "/etc/ai-operator"}
+)
+
+// Environment variables set by the operator, which the env of a job cannot
+// override. NCCL variables are set through networking.nccl.env.
+var (
+	jobReservedEnvNames = []string{
+		"HOME", "TMPDIR", "PYTHONUNBUFFERED",
+		"MODEL_DIR", "OUTPUT_DIR", "INPUT_DIR", "BASE_MODEL_DIR", "CHECKPOINT_PATTERN", "SCHEME",
+		"CHECKSUM_FILE", "MODEL_URL", "MODEL_SHA256",
+		"HF_TOKEN", "HF_ENDPOINT", "HF_HUB_OFFLINE",
+		"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY",
+		"SSL_CERT_FILE", "REQUESTS_CA_BUNDLE", "CURL_CA_BUNDLE", "AWS_CA_BUNDLE",
+		"MLFLOW_TRACKING_URI", "MLFLOW_EXPERIMENT_NAME", "MLFLOW_RUN_ID",
+		"MLFLOW_TRACKING_USERNAME", "MLFLOW_TRACKING_PASSWORD", "MLFLOW_TRACKING_TOKEN",
+		"WANDB_BASE_URL", "WANDB_PROJECT", "WANDB_ENTITY", "WANDB_RUN_ID", "WANDB_RESUME", "WANDB_TAGS",
+	}
+	jobReservedEnvPrefixes = []string{"WORKSPACE_", "NCCL_"}
 )
 
 // NOTE: json tags are required.
@@ -37,12 +87,44 @@ const (
 type JobSpec struct {
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// Container image to use
+	// JobTemplate or ClusterJobTemplate the job is based on. The fields set
+	// on the job override the ones of the template.
+	TemplateRef *TemplateReference `json:"templateRef,omitempty"`
+
+	// Training framework, selecting how the model is downloaded, trained and
+	// how the progress is read from the logs
+	Framework Framework `json:"framework,omitempty"`
+
+	// Container image to use. Tags are resolved to a digest when the job
+	// starts, so that retries and postprocess steps run the same image.
 	Image string `json:"image,omitempty"`
 
+	// Pull policy of the containers of the training and postprocess pods
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
+	// Secrets in the job namespace holding the credentials of the
+	// registries, used by the pods and to resolve the image digest
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
 	// Model to train
 	Model string `json:"model,omitempty"`
 
+	// Where the model is downloaded from, defaults to the Hugging Face Hub
+	ModelSource *ModelSource `json:"modelSource,omitempty"`
+
+	// How the containers reach external services, overriding the settings
+	// of the operator
+	Egress *JobEgress `json:"egress,omitempty"`
+
+	// Restrict the egress of the training and postprocess pods to the
+	// destinations of each phase, which have full network access otherwise
+	Network *JobNetwork `json:"network,omitempty"`
+
+	// Networking of the training pod for multi-node and multi-GPU runs:
+	// secondary networks, RDMA devices and NCCL tuning
+	Networking *JobNetworking `json:"networking,omitempty"`
+
 	// Runtime class name for the job
 	RuntimeClassName string `json:"runtimeClassName,omitempty"`
 
@@ -55,17 +137,664 @@ type JobSpec struct {
 	// Access modes for the disk
 	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
 
-	// Command to run in the container
+	// Command to run in the container, defaults to the example training of
+	// the framework. Required for the axolotl and custom frameworks.
 	Command []string `json:"command,omitempty"`
 
-	// HuggingFace secret for downloading the model
+	// Arguments appended to the command of the training container. They are
+	// not passed to the download container, whose command depends on the
+	// model source and would reject training arguments.
+	Args []string `json:"args,omitempty"`
+
+	// Environment variables of the download and training containers. The
+	// variables set by the operator cannot be overridden.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// ConfigMaps and Secrets whose keys become environment variables of the
+	// download and training containers. The env of the job and the variables
+	// of the operator take precedence.
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
+	// HuggingFace token for downloading the model, required when the model
+	// is downloaded from the Hugging Face Hub
 	HuggingFaceSecret string `json:"huggingFaceSecret,omitempty"`
+
+	// Number of GPUs requested by the training container
+	GPUs int32 `json:"gpus,omitempty"`
+
+	// Labels of the nodes the training and postprocess pods may run on
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the training and postprocess pods
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Layout of the job volume, mounted at /workspace by default
+	Workspace *JobWorkspace `json:"workspace,omitempty"`
+
+	// Size of the in-memory /dev/shm of the training and postprocess
+	// containers, used by data loader workers and NCCL and counted in their
+	// memory usage. Defaults to the 64Mi of the container runtime.
+	ShmSize *resource.Quantity `json:"shmSize,omitempty"`
+
+	// Volume of the /scratch directory holding the home and temporary files
+	// of the containers, an emptyDir without size limit by default
+	Scratch *JobScratch `json:"scratch,omitempty"`
+
+	// Volumes of the training and postprocess pods, like ConfigMaps, Secrets
+	// and PersistentVolumeClaims holding datasets
+	ExtraVolumes []corev1.Volume `json:"extraVolumes,omitempty"`
+
+	// Mounts of the extra volumes in the training and postprocess containers
+	ExtraVolumeMounts []corev1.VolumeMount `json:"extraVolumeMounts,omitempty"`
+
+	// Existing ServiceAccount the training and postprocess pods run as
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+
+	// ServiceAccount created for the job, named after it and deleted with it
+	ServiceAccount *JobServiceAccount `json:"serviceAccount,omitempty"`
+
+	// Security context of the pods, whose fields override those of the
+	// default that runs as user 1000 with the RuntimeDefault seccomp profile
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+
+	// Security context of the containers, whose fields override those of the
+	// default that drops all capabilities and makes the root filesystem
+	// read-only
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+
+	// Name of the Queue in the job namespace that admits the job.
+	// Falls back to the "default" Queue when it exists.
+	QueueName string `json:"queueName,omitempty"`
+
+	// Priority of the job within its queue, higher values are admitted first
+	Priority int32 `json:"priority,omitempty"`
+
+	// Suspend the job, stopping its pods while keeping the volume and checkpoints.
+	// Resuming continues the training from the last checkpoint.
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Duration in seconds the batch job may run before it is terminated
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// Number of retries before the job is marked as failed
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// Seconds after the job finished before its batch jobs are deleted and its
+	// volume is released following the retention policy. The AI Job is kept
+	// with its final status, the secret shared with the other jobs of the
+	// namespace is kept too.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// Classify the exit codes of the training container
+	FailurePolicy *JobFailurePolicy `json:"failurePolicy,omitempty"`
+
+	// What happens to the volume holding the model and outputs when the job
+	// is deleted or its resources expire
+	RetentionPolicy RetentionPolicy `json:"retentionPolicy,omitempty"`
+
+	// Log the training metrics to an experiment tracker
+	Tracking *JobTracking `json:"tracking,omitempty"`
+
+	// Serve the TensorBoard event files of the job volume
+	TensorBoard *JobTensorBoard `json:"tensorboard,omitempty"`
+
+	// HTTP endpoints notified when the job changes state
+	// +listType=map
+	// +listMapKey=name
+	Notifications []JobNotification `json:"notifications,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+
+	// Fine-tuning method, defaults to a full fine-tune
+	Method *JobMethod `json:"method,omitempty"`
+
+	// Steps converting the trained weights after the training, run in order
+	// on the job volume
+	// +listType=map
+	// +listMapKey=name
+	Postprocess []PostprocessStep `json:"postprocess,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+}
+
+// JobNetwork lists the destinations the pods of an AI Job may reach in each
+// phase. DNS is always allowed.
+type JobNetwork struct {
+	// Destinations of the model download, like the Hugging Face Hub, its
+	// mirror or the object storage holding the model
+	Download []networkingv1.NetworkPolicyEgressRule `json:"download,omitempty"`
+
+	// Destinations of the training and postprocess steps, like the tracking
+	// server or the object storage receiving uploads
+	Training []networkingv1.NetworkPolicyEgressRule `json:"training,omitempty"`
+}
+
+// JobWorkspace locates the data of an AI Job on its volume. Directories are
+// relative to the volume and exposed to all containers as WORKSPACE_DIR,
+// WORKSPACE_MODEL_DIR, WORKSPACE_DATA_DIR, WORKSPACE_OUTPUT_DIR,
+// WORKSPACE_CHECKPOINT_DIR and WORKSPACE_LOG_DIR.
+type JobWorkspace struct {
+	// Where the job volume is mounted in the containers
+	MountPath string `json:"mountPath,omitempty"`
+
+	// Directory receiving the downloaded model
+	Model string `json:"model,omitempty"`
+
+	// Directory of the datasets
+	Data string `json:"data,omitempty"`
+
+	// Directory receiving the trained weights. The built-in frameworks also
+	// keep their checkpoints there, as they resume from it.
+	Output string `json:"output,omitempty"`
+
+	// Directory of the checkpoints of frameworks writing them apart
+	Checkpoints string `json:"checkpoints,omitempty"`
+
+	// Directory of the logs and TensorBoard event files
+	Logs string `json:"logs,omitempty"`
+}
+
+// ScratchType is the kind of volume backing the scratch directory
+// +kubebuilder:validation:Enum=EmptyDir;Ephemeral
+type ScratchType string
+
+const (
+	// Directory on the disk of the node
+	ScratchEmptyDir ScratchType = "EmptyDir"
+	// Generic ephemeral volume provisioned for the pod and deleted with it
+	ScratchEphemeral ScratchType = "Ephemeral"
+)
+
+// JobScratch is the volume of the scratch directory of the containers
+type JobScratch struct {
+	// Kind of volume, defaults to EmptyDir
+	Type ScratchType `json:"type,omitempty"`
+
+	// Size of the volume, required by Ephemeral volumes
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// Storage class of Ephemeral volumes, the cluster default when empty
+	StorageClassName string `json:"storageClassName,omitempty"`
+}
+
+// JobNetworking configures the fabric used by the collective communications
+// of the training
+type JobNetworking struct {
+	// Multus NetworkAttachmentDefinitions attached to the training pod, as
+	// <name> or <namespace>/<name>
+	Attachments []string `json:"attachments,omitempty"`
+
+	// Extended resources of the RDMA or InfiniBand devices requested by the
+	// training container, like rdma/hca_shared_devices_a: 1
+	RDMAResources corev1.ResourceList `json:"rdmaResources,omitempty"`
+
+	// Run the training pod in the network namespace of the node, for fabrics
+	// without a secondary network plugin. Only allowed when the operator
+	// configuration allows it, and not together with network.
+	HostNetwork bool `json:"hostNetwork,omitempty"`
+
+	// NCCL settings of the training container
+	NCCL *JobNCCL `json:"nccl,omitempty"`
+}
+
+// NCCLDebugLevel is the verbosity of the NCCL logs
+// +kubebuilder:validation:Enum=VERSION;WARN;INFO;TRACE
+type NCCLDebugLevel string
+
+const (
+	NCCLDebugVersion NCCLDebugLevel = "VERSION"
+	NCCLDebugWarn    NCCLDebugLevel = "WARN"
+	NCCLDebugInfo    NCCLDebugLevel = "INFO"
+	NCCLDebugTrace   NCCLDebugLevel = "TRACE"
+)
+
+// JobNCCL tunes the NCCL library used by the training frameworks
+type JobNCCL struct {
+	// Interfaces of the socket transport, like net1 or ^lo,docker, set as
+	// NCCL_SOCKET_IFNAME
+	SocketInterface string `json:"socketInterface,omitempty"`
+
+	// InfiniBand adapters and ports, like mlx5_0:1,mlx5_1:1, set as
+	// NCCL_IB_HCA
+	IBHCA string `json:"ibHCA,omitempty"`
+
+	// Disable the InfiniBand transport, set as NCCL_IB_DISABLE
+	IBDisable bool `json:"ibDisable,omitempty"`
+
+	// Verbosity of the logs, set as NCCL_DEBUG
+	DebugLevel NCCLDebugLevel `json:"debugLevel,omitempty"`
+
+	// Other NCCL variables, overriding the ones above
+	Env []corev1.EnvVar `json:"env,omitempty"`
+}
+
+// JobServiceAccount is the ServiceAccount the operator creates for an AI Job,
+// usually bound to a cloud identity to reach object storage without keys.
+type JobServiceAccount struct {
+	// Annotations of the ServiceAccount, like eks.amazonaws.com/role-arn,
+	// iam.gke.io/gcp-service-account or azure.workload.identity/client-id
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Labels of the pods running as the ServiceAccount, like
+	// azure.workload.identity/use
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// Rules of the Role bound to the ServiceAccount in the job namespace
+	Rules []rbacv1.PolicyRule `json:"rules,omitempty"`
+}
+
+// Framework is the training framework run by the job.
+// +kubebuilder:validation:Enum=torchtune;trl;axolotl;custom
+type Framework string
+
+const (
+	// FrameworkTorchtune trains with torchtune recipes
+	FrameworkTorchtune Framework = "torchtune"
+	// FrameworkTRL trains with the Hugging Face TRL command line
+	FrameworkTRL Framework = "trl"
+	// FrameworkAxolotl trains with an Axolotl config
+	FrameworkAxolotl Framework = "axolotl"
+	// FrameworkCustom runs the command as is
+	FrameworkCustom Framework = "custom"
+)
+
+// Default container images of the frameworks
+var jobDefaultImages = map[Framework]string{
+	FrameworkTorchtune: "silentehrec/torchtune:latest",
+	FrameworkTRL:       "huggingface/trl-latest-gpu:latest",
+	FrameworkAxolotl:   "axolotlai/axolotl:main-latest",
+}
+
+// ModelSourceType is where the model is downloaded from.
+// +kubebuilder:validation:Enum=HuggingFace;ObjectStorage;HTTP;PVC;VolumeSnapshot;OCI
+type ModelSourceType string
+
+const (
+	// ModelSourceHuggingFace downloads the model from the Hugging Face Hub or a mirror
+	ModelSourceHuggingFace ModelSourceType = "HuggingFace"
+	// ModelSourceObjectStorage copies the model from an S3 compatible bucket
+	ModelSourceObjectStorage ModelSourceType = "ObjectStorage"
+	// ModelSourceHTTP downloads and extracts a tarball of the model
+	ModelSourceHTTP ModelSourceType = "HTTP"
+	// ModelSourcePVC copies the model from an existing volume
+	ModelSourcePVC ModelSourceType = "PVC"
+	// ModelSourceVolumeSnapshot restores the job volume from a snapshot holding the model
+	ModelSourceVolumeSnapshot ModelSourceType = "VolumeSnapshot"
+	// ModelSourceOCI copies the model from an OCI image
+	ModelSourceOCI ModelSourceType = "OCI"
+)
+
+// ModelSource locates the weights of the model trained by the job. The model
+// is always written to the job volume before the training starts.
+type ModelSource struct {
+	// Kind of source
+	Type ModelSourceType `json:"type"`
+
+	// Hugging Face Hub settings
+	HuggingFace *HuggingFaceSource `json:"huggingFace,omitempty"`
+
+	// S3 or GCS bucket settings
+	ObjectStorage *ObjectStorageSource `json:"objectStorage,omitempty"`
+
+	// Tarball settings
+	HTTP *HTTPSource `json:"http,omitempty"`
+
+	// Volume settings
+	PVC *PVCSource `json:"pvc,omitempty"`
+
+	// Snapshot settings
+	VolumeSnapshot *VolumeSnapshotSource `json:"volumeSnapshot,omitempty"`
+
+	// OCI image settings
+	OCI *OCISource `json:"oci,omitempty"`
+
+	// File of SHA-256 checksums in the sha256sum format, relative to the
+	// model directory. The model files are verified against it before the
+	// training starts.
+	ChecksumFile string `json:"checksumFile,omitempty"`
+
+	// Container image running the download, defaults to the job image for
+	// the Hugging Face Hub and to a minimal image providing the tools of the
+	// other sources. Not used by OCI sources.
+	Image string `json:"image,omitempty"`
+}
+
+// HuggingFaceSource locates the Hugging Face Hub.
+type HuggingFaceSource struct {
+	// URL of a mirror of the Hub, set as HF_ENDPOINT for the download and
+	// the training
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// ObjectStorageSource locates the model in a bucket.
+type ObjectStorageSource struct {
+	// URI of the directory holding the model, s3://bucket/prefix or
+	// gs://bucket/prefix
+	URI string `json:"uri"`
+
+	// URL of an S3 compatible endpoint, defaults to AWS for s3 URIs and to
+	// the interoperability endpoint of Google Cloud Storage for gs URIs
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Name of a Secret in the job namespace whose keys are exposed as
+	// environment variables, e.g. AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// HTTPSource locates a tarball of the model.
+type HTTPSource struct {
+	// HTTP or HTTPS URL of a tar archive, optionally gzip compressed, holding
+	// the model files at its root
+	URL string `json:"url"`
+
+	// Hex encoded SHA-256 checksum of the archive
+	SHA256 string `json:"sha256"`
+}
+
+// PVCSource locates the model on an existing volume.
+type PVCSource struct {
+	// Name of the PersistentVolumeClaim in the job namespace
+	ClaimName string `json:"claimName"`
+
+	// Directory of the model on the volume, defaults to its root
+	Path string `json:"path,omitempty"`
+}
+
+// VolumeSnapshotSource restores the job volume from a snapshot, like the
+// snapshot of the volume of an earlier job.
+type VolumeSnapshotSource struct {
+	// Name of the VolumeSnapshot in the job namespace
+	Name string `json:"name"`
+
+	// Directory of the model in the snapshot, defaults to the model
+	// directory of the workspace
+	Path string `json:"path,omitempty"`
+}
+
+// OCISource locates the model in an OCI image, like a KServe modelcar.
+type OCISource struct {
+	// Image holding the model files, which must provide sh and cp. Pin it by
+	// digest to make sure the same model is trained.
+	Image string `json:"image"`
+
+	// Directory of the model in the image
+	Path string `json:"path,omitempty"`
+}
+
+// JobEgress configures how the download, training and postprocess containers
+// reach external services, like a Hugging Face mirror behind a proxy. Unset
+// fields fall back to the settings of the operator.
+type JobEgress struct {
+	// URL of a Hugging Face Hub mirror, set as HF_ENDPOINT
+	HuggingFaceEndpoint string `json:"huggingFaceEndpoint,omitempty"`
+
+	// Never contact the Hub, setting HF_HUB_OFFLINE. The model must come from
+	// another source and the datasets must be available offline.
+	HuggingFaceOffline *bool `json:"huggingFaceOffline,omitempty"`
+
+	// Proxy of the HTTP requests, set as HTTP_PROXY
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// Proxy of the HTTPS requests, set as HTTPS_PROXY
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// Comma separated hosts and domains reached without proxy, set as NO_PROXY
+	NoProxy string `json:"noProxy,omitempty"`
+
+	// CA certificates trusted by the containers instead of the ones of their
+	// image, like the CA of an internal mirror
+	CABundle *CABundle `json:"caBundle,omitempty"`
+}
+
+// CABundle locates PEM encoded CA certificates in a ConfigMap.
+type CABundle struct {
+	// Name of the ConfigMap in the job namespace
+	ConfigMapName string `json:"configMapName"`
+
+	// Key of the certificates in the ConfigMap
+	Key string `json:"key,omitempty"`
+}
+
+// RetentionPolicy decides whether the volume of a job outlives it.
+// +kubebuilder:validation:Enum=Delete;Retain;RetainOnSuccess
+type RetentionPolicy string
+
+const (
+	// RetentionPolicyDelete deletes the volume with the job
+	RetentionPolicyDelete RetentionPolicy = "Delete"
+	// RetentionPolicyRetain always keeps the volume
+	RetentionPolicyRetain RetentionPolicy = "Retain"
+	// RetentionPolicyRetainOnSuccess keeps the volume of succeeded jobs only
+	RetentionPolicyRetainOnSuccess RetentionPolicy = "RetainOnSuccess"
+)
+
+// JobFailurePolicy decides which training failures are retried.
+// Exit codes that are not listed count against the backoff limit.
+type JobFailurePolicy struct {
+	// Exit codes that are retried, like out of memory or CUDA errors
+	RetryableExitCodes []int32 `json:"retryableExitCodes,omitempty"`
+
+	// Exit codes that fail the job without retrying, like configuration errors
+	FatalExitCodes []int32 `json:"fatalExitCodes,omitempty"`
+}
+
+// TrackingProvider is the experiment tracker receiving the training metrics.
+// +kubebuilder:validation:Enum=MLflow;WandB;TensorBoard
+type TrackingProvider string
+
+const (
+	// TrackingProviderMLflow logs to an MLflow tracking server
+	TrackingProviderMLflow TrackingProvider = "MLflow"
+	// TrackingProviderWandB logs to Weights & Biases
+	TrackingProviderWandB TrackingProvider = "WandB"
+	// TrackingProviderTensorBoard writes TensorBoard event files to the volume
+	TrackingProviderTensorBoard TrackingProvider = "TensorBoard"
+)
+
+// JobTracking configures the metric logger of the training.
+type JobTracking struct {
+	// Experiment tracker receiving the metrics
+	Provider TrackingProvider `json:"provider"`
+
+	// MLflow tracking server settings
+	MLflow *MLflowTracking `json:"mlflow,omitempty"`
+
+	// Weights & Biases settings
+	WandB *WandBTracking `json:"wandb,omitempty"`
+
+	// TensorBoard settings
+	TensorBoard *TensorBoardTracking `json:"tensorboard,omitempty"`
+
+	// Name of a Secret in the job namespace whose keys are exposed as environment
+	// variables to the training, e.g. MLFLOW_TRACKING_TOKEN or WANDB_API_KEY
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// MLflowTracking locates the MLflow experiment of the job.
+type MLflowTracking struct {
+	// URL of the MLflow tracking server
+	TrackingURI string `json:"trackingURI"`
+
+	// Experiment the run is created in, defaults to the job namespace
+	ExperimentName string `json:"experimentName,omitempty"`
+}
+
+// WandBTracking locates the Weights & Biases project of the job.
+type WandBTracking struct {
+	// Project the run is logged to
+	Project string `json:"project"`
+
+	// Team or user owning the project
+	Entity string `json:"entity,omitempty"`
+
+	// URL of a self-hosted server, defaults to https://wandb.ai
+	BaseURL string `json:"baseURL,omitempty"`
+}
+
+// TensorBoardTracking locates the TensorBoard event files on the job volume.
+type TensorBoardTracking struct {
+	// Directory on the job volume receiving the event files
+	LogDir string `json:"logDir,omitempty"`
+}
+
+// JobTensorBoard runs a TensorBoard viewer on the job volume.
+type JobTensorBoard struct {
+	// Run the viewer
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Container image providing the tensorboard command
+	Image string `json:"image,omitempty"`
+
+	// Seconds after the job finished before the viewer is removed
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// NotificationFormat is the payload sent to a notification endpoint.
+// +kubebuilder:validation:Enum=JSON;Slack;CloudEvents
+type NotificationFormat string
+
+const (
+	// NotificationFormatJSON posts the event as JSON, or the rendered template
+	NotificationFormatJSON NotificationFormat = "JSON"
+	// NotificationFormatSlack posts a Slack incoming webhook message
+	NotificationFormatSlack NotificationFormat = "Slack"
+	// NotificationFormatCloudEvents posts a CloudEvent in binary content mode
+	NotificationFormatCloudEvents NotificationFormat = "CloudEvents"
+)
+
+// JobNotification is an HTTP endpoint notified of state changes.
+type JobNotification struct {
+	// Name of the notification, reported in the status
+	Name string `json:"name"`
+
+	// URL receiving the POST requests
+	URL string `json:"url"`
+
+	// Payload format
+	Format NotificationFormat `json:"format,omitempty"`
+
+	// Go template of the JSON payload, rendered with the event fields like
+	// .Job, .Namespace, .State, .Details, .Model and .RunURL, escaped for JSON
+	// strings
+	Template string `json:"template,omitempty"`
+
+	// States that trigger the notification, defaults to Succeeded and Failed
+	Events []JobState `json:"events,omitempty"`
+
+	// Name of a Secret in the job namespace whose "key" signs the payloads
+	// with HMAC-SHA256
+	SigningSecret string `json:"signingSecret,omitempty"`
+}
+
+// MethodType is the fine-tuning method.
+// +kubebuilder:validation:Enum=full;lora;qlora;dora
+type MethodType string
+
+const (
+	// MethodFull trains all the weights of the model
+	MethodFull MethodType = "full"
+	// MethodLoRA trains low-rank adapters
+	MethodLoRA MethodType = "lora"
+	// MethodQLoRA trains low-rank adapters on a quantized base model
+	MethodQLoRA MethodType = "qlora"
+	// MethodDoRA trains weight-decomposed low-rank adapters
+	MethodDoRA MethodType = "dora"
+)
+
+// JobMethod selects the fine-tuning method and its parameters.
+type JobMethod struct {
+	// Fine-tuning method
+	Type MethodType `json:"type"`
+
+	// Rank of the adapters, defaults to the value of the config
+	Rank int32 `json:"rank,omitempty"`
+
+	// Scaling of the adapters, defaults to the value of the config
+	Alpha int32 `json:"alpha,omitempty"`
+
+	// Modules receiving adapters: q_proj, k_proj, v_proj, output_proj, mlp and output
+	TargetModules []string `json:"targetModules,omitempty"`
+
+	// Merge the adapters into the base weights, producing standalone weights
+	// for serving next to the adapters
+	Merge bool `json:"merge,omitempty"`
+}
+
+// Adapter reports whether the method trains adapters instead of the full model
+func (jm *JobMethod) Adapter() bool {
+	return jm != nil && jm.Type != MethodFull
+}
+
+// PostprocessStepType is the conversion done by a postprocess step.
+// +kubebuilder:validation:Enum=Merge;Quantize;ConvertGGUF;Custom
+type PostprocessStepType string
+
+const (
+	// PostprocessMerge merges adapters into the base model with PEFT
+	PostprocessMerge PostprocessStepType = "Merge"
+	// PostprocessQuantize quantizes the weights with LLM Compressor
+	PostprocessQuantize PostprocessStepType = "Quantize"
+	// PostprocessConvertGGUF converts the weights to GGUF with llama.cpp
+	PostprocessConvertGGUF PostprocessStepType = "ConvertGGUF"
+	// PostprocessCustom runs the command of the step
+	PostprocessCustom PostprocessStepType = "Custom"
+)
+
+// PostprocessStep converts the output of the training or of a previous step.
+type PostprocessStep struct {
+	// Name of the step, its output is written to postprocess/<name> on the volume
+	Name string `json:"name"`
+
+	// Conversion done by the step
+	Type PostprocessStepType `json:"type"`
+
+	// Quantization scheme: awq or gptq for Quantize, a llama.cpp type like
+	// q4_k_m, q8_0 or f16 for ConvertGGUF
+	Scheme string `json:"scheme,omitempty"`
+
+	// Name of the step whose output is converted, defaults to the previous
+	// step or the training output for the first step
+	Input string `json:"input,omitempty"`
+
+	// Container image of the step, defaults to the job image, or to the
+	// llama.cpp image for ConvertGGUF
+	Image string `json:"image,omitempty"`
+
+	// Command of Custom steps, run with the INPUT_DIR, MODEL_DIR and OUTPUT_DIR
+	// environment variables
+	Command []string `json:"command,omitempty"`
+}
+
+// Images returns the container images run by the job, some of them empty
+// until the spec is validated
+func (js *JobSpec) Images() []string {
+	images := []string{js.Image}
+	if source := js.ModelSource; source != nil {
+		images = append(images, source.Image)
+		if source.OCI != nil {
+			images = append(images, source.OCI.Image)
+		}
+	}
+	if js.TensorBoard != nil && js.TensorBoard.Enabled {
+		images = append(images, js.TensorBoard.Image)
+	}
+	for _, step := range js.Postprocess {
+		images = append(images, step.Image)
+	}
+	return images
 }
 
 func (js *JobSpec) Validate() error {
+	// Validate the Framework field
+	if js.Framework == "" {
+		js.Framework = FrameworkTorchtune
+	}
+	if (js.Framework == FrameworkAxolotl || js.Framework == FrameworkCustom) && len(js.Command) == 0 {
+		return fmt.Errorf("the %s framework requires a command", js.Framework)
+	}
+
 	// Validate the Image field
 	if js.Image == "" {
-		js.Image = jobDefaultImageName
+		js.Image = jobDefaultImages[js.Framework]
+	}
+	if js.Image == "" {
+		return fmt.Errorf("the %s framework requires an image", js.Framework)
 	}
 
 	// Validate the Model field
@@ -73,6 +802,52 @@ func (js *JobSpec) Validate() error {
 		js.Model = jobDefaultModelName
 	}
 
+	// Validate the Env field
+	for _, env := range js.Env {
+		if errs := validation.IsEnvVarName(env.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid environment variable %s: %s", env.Name, strings.Join(errs, ", "))
+		}
+		if slices.Contains(jobReservedEnvNames, env.Name) ||
+			slices.ContainsFunc(jobReservedEnvPrefixes, func(prefix string) bool { return strings.HasPrefix(env.Name, prefix) }) {
+			return fmt.Errorf("environment variable %s is set by the operator", env.Name)
+		}
+	}
+
+	// Validate the Workspace field
+	if err := js.validateWorkspace(); err != nil {
+		return err
+	}
+
+	// Validate the ModelSource field
+	if js.ModelSource == nil {
+		js.ModelSource = &ModelSource{Type: ModelSourceHuggingFace}
+	}
+	if js.ModelSource.VolumeSnapshot != nil && js.ModelSource.VolumeSnapshot.Path == "" {
+		js.ModelSource.VolumeSnapshot.Path = js.Workspace.Model
+	}
+	if err := js.ModelSource.validate(js.Image); err != nil {
+		return err
+	}
+
+	// Validate the ServiceAccountName field
+	if js.ServiceAccountName != "" {
+		if js.ServiceAccount != nil {
+			return fmt.Errorf("serviceAccountName and serviceAccount are mutually exclusive")
+		}
+		if errs := validation.IsDNS1123Subdomain(js.ServiceAccountName); len(errs) > 0 {
+			return fmt.Errorf("invalid serviceAccountName %s: %s", js.ServiceAccountName, strings.Join(errs, ", "))
+		}
+	}
+
+	// Validate the Egress field
+	if err := js.Egress.Validate(); err != nil {
+		return err
+	}
+	if js.Egress != nil && js.Egress.HuggingFaceOffline != nil && *js.Egress.HuggingFaceOffline &&
+		js.ModelSource.Type == ModelSourceHuggingFace {
+		return fmt.Errorf("offline jobs must download their model from another source than the Hugging Face Hub")
+	}
+
 	// Validate the RuntimeClassName field
 	if js.RuntimeClassName == "" {
 		js.RuntimeClassName = "nvidia"
@@ -93,35 +868,504 @@ func (js *JobSpec) Validate() error {
 		js.AccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
 	}
 
-	// Validate the Command field
-	if len(js.Command) == 0 {
-		js.Command = []string{
-			"tune",
-			"run",
-			"full_finetune_single_device",
-			"-r=3",
-			"--config",
-			"qwen2_5/0.5B_full_single_device",
+	// Validate the Method field
+	if js.Method != nil {
+		if js.Method.Type == MethodFull &&
+			(js.Method.Rank != 0 || js.Method.Alpha != 0 || len(js.Method.TargetModules) > 0 || js.Method.Merge) {
+			return fmt.Errorf("adapter parameters require a lora, qlora or dora method")
+		}
+		if js.Method.Rank < 0 || js.Method.Alpha < 0 {
+			return fmt.Errorf("adapter rank and alpha must not be negative")
+		}
+		for _, module := range js.Method.TargetModules {
+			if !slices.Contains(jobAdapterTargetModules, module) {
+				return fmt.Errorf("unknown adapter target module %s", module)
+			}
+		}
+		switch js.Framework {
+		case FrameworkTorchtune:
+		case FrameworkCustom:
+			return fmt.Errorf("the method of custom frameworks is set in their command")
+		default:
+			if js.Method.Merge {
+				return fmt.Errorf("the %s framework cannot merge adapters, use a Merge postprocess step", js.Framework)
+			}
+			if js.Framework == FrameworkAxolotl && len(js.Method.TargetModules) > 0 {
+				return fmt.Errorf("the axolotl framework reads the adapter target modules from its config")
+			}
+		}
+	}
+
+	// Validate the GPUs field
+	if js.GPUs < 0 {
+		return fmt.Errorf("GPUs must not be negative")
+	}
+
+	// Validate the ShmSize field
+	if js.ShmSize != nil && js.ShmSize.Sign() <= 0 {
+		return fmt.Errorf("shmSize must be positive")
+	}
+
+	// Validate the Scratch field
+	if scratch := js.Scratch; scratch != nil {
+		if scratch.Type == "" {
+			scratch.Type = ScratchEmptyDir
+		}
+		if scratch.Size != nil && scratch.Size.Sign() <= 0 {
+			return fmt.Errorf("scratch size must be positive")
+		}
+		if scratch.Type == ScratchEphemeral && scratch.Size == nil {
+			return fmt.Errorf("ephemeral scratch volumes require a size")
+		}
+		if scratch.Type == ScratchEmptyDir && scratch.StorageClassName != "" {
+			return fmt.Errorf("only ephemeral scratch volumes have a storage class")
+		}
+	}
+
+	// Validate the ExtraVolumes and ExtraVolumeMounts fields
+	if err := js.validateExtraVolumes(); err != nil {
+		return err
+	}
+
+	// Validate the Networking field
+	if networking := js.Networking; networking != nil {
+		for _, attachment := range networking.Attachments {
+			for _, name := range strings.SplitN(attachment, "/", 2) {
+				if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+					return fmt.Errorf("invalid network attachment %s: %s", attachment, strings.Join(errs, ", "))
+				}
+			}
+		}
+		if networking.HostNetwork && js.Network != nil {
+			return fmt.Errorf("networking.hostNetwork cannot be used with network, whose NetworkPolicies do not apply to host network pods")
+		}
+		if networking.NCCL != nil {
+			for _, env := range networking.NCCL.Env {
+				if !strings.HasPrefix(env.Name, "NCCL_") {
+					return fmt.Errorf("NCCL variable %s must start with NCCL_", env.Name)
+				}
+			}
+		}
+	}
+
+	// Validate the RetentionPolicy field
+	if js.RetentionPolicy == "" {
+		js.RetentionPolicy = RetentionPolicyDelete
+	}
+
+	// Validate the FailurePolicy field
+	if js.FailurePolicy != nil {
+		exitCodes := append(slices.Clone(js.FailurePolicy.RetryableExitCodes), js.FailurePolicy.FatalExitCodes...)
+		for _, code := range exitCodes {
+			if code < 1 || code > 255 {
+				return fmt.Errorf("exit code %d must be between 1 and 255", code)
+			}
+		}
+		for _, code := range js.FailurePolicy.FatalExitCodes {
+			if slices.Contains(js.FailurePolicy.RetryableExitCodes, code) {
+				return fmt.Errorf("exit code %d cannot be both retryable and fatal", code)
+			}
+		}
+	}
+
+	// Validate the Tracking field, writing the event files to the logs
+	if tracking := js.Tracking; tracking != nil && tracking.Provider == TrackingProviderTensorBoard &&
+		(tracking.TensorBoard == nil || tracking.TensorBoard.LogDir == "") {
+		if tracking.TensorBoard == nil {
+			tracking.TensorBoard = &TensorBoardTracking{}
+		}
+		tracking.TensorBoard.LogDir = path.Join(js.Workspace.Logs, jobDefaultTensorBoardLogDir)
+	}
+	if err := js.Tracking.validate(); err != nil {
+		return err
+	}
+
+	// Validate the TensorBoard field
+	if tb := js.TensorBoard; tb != nil {
+		if tb.Image == "" {
+			tb.Image = jobDefaultTensorBoardImage
+		}
+		if tb.TTLSecondsAfterFinished == nil {
+			ttl := int32(jobDefaultTensorBoardTTLSeconds)
+			tb.TTLSecondsAfterFinished = &ttl
+		}
+	}
+
+	// Validate the Notifications field
+	names := map[string]bool{}
+	for i := range js.Notifications {
+		notification := &js.Notifications[i]
+		if notification.Name == "" || names[notification.Name] {
+			return fmt.Errorf("notification names must be set and unique")
+		}
+		names[notification.Name] = true
+		if u, err := url.Parse(notification.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("notification %s requires an http or https URL", notification.Name)
+		}
+		if notification.Format == "" {
+			notification.Format = NotificationFormatJSON
+		}
+		if len(notification.Events) == 0 {
+			notification.Events = []JobState{JobStateSucceeded, JobStateFailed}
+		}
+	}
+
+	// Validate the Postprocess field
+	steps := map[string]bool{}
+	for i := range js.Postprocess {
+		step := &js.Postprocess[i]
+		if errs := validation.IsDNS1123Label(step.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid postprocess step name %s: %s", step.Name, strings.Join(errs, ", "))
+		}
+		if steps[step.Name] {
+			return fmt.Errorf("duplicate postprocess step %s", step.Name)
+		}
+		if step.Input != "" && !steps[step.Input] {
+			return fmt.Errorf("postprocess step %s must read from an earlier step, not %s", step.Name, step.Input)
+		}
+		steps[step.Name] = true
+
+		switch step.Type {
+		case PostprocessQuantize:
+			if step.Scheme != "awq" && step.Scheme != "gptq" {
+				return fmt.Errorf("postprocess step %s must quantize with awq or gptq", step.Name)
+			}
+		case PostprocessConvertGGUF:
+			if step.Scheme == "" {
+				step.Scheme = jobDefaultGGUFScheme
+			}
+			if step.Image == "" {
+				step.Image = jobDefaultLlamaCppImage
+			}
+		case PostprocessCustom:
+			if len(step.Command) == 0 {
+				return fmt.Errorf("custom postprocess step %s requires a command", step.Name)
+			}
+		}
+		if step.Image == "" {
+			step.Image = js.Image
 		}
 	}
 
 	// Validate the HuggingFaceSecret field
-	if js.HuggingFaceSecret == "" {
+	if js.HuggingFaceSecret == "" && js.ModelSource.Type == ModelSourceHuggingFace {
 		return fmt.Errorf("HuggingFaceSecret is required")
 	}
 
 	return nil
 }
 
+func (ms *ModelSource) validate(image string) error {
+	switch ms.Type {
+	case ModelSourceHuggingFace:
+		if ms.HuggingFace != nil && ms.HuggingFace.Endpoint != "" {
+			if u, err := url.Parse(ms.HuggingFace.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return fmt.Errorf("the Hugging Face endpoint must be an http or https URL")
+			}
+		}
+		if ms.Image == "" {
+			ms.Image = image
+		}
+	case ModelSourceObjectStorage:
+		if ms.ObjectStorage == nil {
+			return fmt.Errorf("ObjectStorage model sources require a URI")
+		}
+		u, err := url.Parse(ms.ObjectStorage.URI)
+		if err != nil || (u.Scheme != "s3" && u.Scheme != "gs") || u.Host == "" {
+			return fmt.Errorf("the model URI must be an s3:// or gs:// URI")
+		}
+		if ms.ObjectStorage.Endpoint == "" && u.Scheme == "gs" {
+			ms.ObjectStorage.Endpoint = jobGCSEndpoint
+		}
+		if ms.Image == "" {
+			ms.Image = jobDefaultAWSCLIImage
+		}
+	case ModelSourceHTTP:
+		if ms.HTTP == nil {
+			return fmt.Errorf("HTTP model sources require a URL")
+		}
+		if u, err := url.Parse(ms.HTTP.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("the model URL must be an http or https URL")
+		}
+		if checksum, err := hex.DecodeString(ms.HTTP.SHA256); err != nil || len(checksum) != sha256.Size {
+			return fmt.Errorf("HTTP model sources require the hex encoded SHA-256 checksum of the archive")
+		}
+		if ms.Image == "" {
+			ms.Image = jobDefaultCurlImage
+		}
+	case ModelSourcePVC:
+		if ms.PVC == nil || ms.PVC.ClaimName == "" {
+			return fmt.Errorf("PVC model sources require a claim name")
+		}
+		if ms.PVC.Path != "" && !filepath.IsLocal(ms.PVC.Path) {
+			return fmt.Errorf("the model path %s must be relative to the volume", ms.PVC.Path)
+		}
+		if ms.Image == "" {
+			ms.Image = jobDefaultBusyboxImage
+		}
+	case ModelSourceVolumeSnapshot:
+		if ms.VolumeSnapshot == nil || ms.VolumeSnapshot.Name == "" {
+			return fmt.Errorf("VolumeSnapshot model sources require a snapshot name")
+		}
+		if !filepath.IsLocal(ms.VolumeSnapshot.Path) {
+			return fmt.Errorf("the model path %s must be relative to the volume", ms.VolumeSnapshot.Path)
+		}
+		if ms.Image == "" {
+			ms.Image = jobDefaultBusyboxImage
+		}
+	case ModelSourceOCI:
+		if ms.OCI == nil || ms.OCI.Image == "" {
+			return fmt.Errorf("OCI model sources require an image")
+		}
+		if ms.Image != "" {
+			return fmt.Errorf("the image of OCI model sources is set in oci.image")
+		}
+		if ms.OCI.Path == "" {
+			ms.OCI.Path = jobDefaultOCIModelPath
+		}
+		if !path.IsAbs(ms.OCI.Path) {
+			return fmt.Errorf("the model path %s must be absolute in the image", ms.OCI.Path)
+		}
+	default:
+		return fmt.Errorf("unknown model source %q", ms.Type)
+	}
+
+	if ms.ChecksumFile != "" && !filepath.IsLocal(ms.ChecksumFile) {
+		return fmt.Errorf("the checksum file %s must be relative to the model directory", ms.ChecksumFile)
+	}
+	return nil
+}
+
+// Validate checks the URLs of the egress settings and defaults the key of
+// the CA bundle. The settings of the operator are validated the same way.
+func (je *JobEgress) Validate() error {
+	if je == nil {
+		return nil
+	}
+
+	urls := []struct{ name, value string }{
+		{"Hugging Face endpoint", je.HuggingFaceEndpoint},
+		{"HTTP proxy", je.HTTPProxy},
+		{"HTTPS proxy", je.HTTPSProxy},
+	}
+	for _, u := range urls {
+		if u.value == "" {
+			continue
+		}
+		if parsed, err := url.Parse(u.value); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("the %s must be an http or https URL", u.name)
+		}
+	}
+
+	if je.CABundle != nil {
+		if je.CABundle.ConfigMapName == "" {
+			return fmt.Errorf("the CA bundle requires a ConfigMap name")
+		}
+		if je.CABundle.Key == "" {
+			je.CABundle.Key = jobDefaultCABundleKey
+		}
+	}
+	return nil
+}
+
+func (jt *JobTracking) validate() error {
+	if jt == nil {
+		return nil
+	}
+
+	switch jt.Provider {
+	case TrackingProviderMLflow:
+		if jt.MLflow == nil || jt.MLflow.TrackingURI == "" {
+			return fmt.Errorf("MLflow tracking requires a tracking URI")
+		}
+	case TrackingProviderWandB:
+		if jt.WandB == nil || jt.WandB.Project == "" {
+			return fmt.Errorf("WandB tracking requires a project")
+		}
+	case TrackingProviderTensorBoard:
+		// Keep the event files on the job volume, Validate defaults the
+		// directory under the logs of the workspace
+		if !filepath.IsLocal(jt.TensorBoard.LogDir) {
+			return fmt.Errorf("TensorBoard log directory %s must be relative to the job volume", jt.TensorBoard.LogDir)
+		}
+	default:
+		return fmt.Errorf("unknown tracking provider %q", jt.Provider)
+	}
+
+	return nil
+}
+
+// JobState is the lifecycle phase of an AI Job.
+type JobState string
+
+const (
+	// JobStateQueued means the job is waiting for capacity in its queue
+	JobStateQueued JobState = "Queued"
+	// JobStateRunning means the batch job has been created
+	JobStateRunning JobState = "Running"
+	// JobStateSucceeded means the batch job completed successfully
+	JobStateSucceeded JobState = "Succeeded"
+	// JobStateFailed means the batch job failed
+	JobStateFailed JobState = "Failed"
+	// JobStateSuspended means the batch job is suspended and its pods are stopped
+	JobStateSuspended JobState = "Suspended"
+	// JobStateRejected means the job cannot start until its spec, or the limits
+	// it is checked against, change
+	JobStateRejected JobState = "Rejected"
+)
+
 // JobStatus defines the observed state of Job.
 type JobStatus struct {
 	// Important: Run "make" to regenerate code after modifying this file
-	State   string `json:"state,omitempty"`
-	Details string `json:"details,omitempty"`
+	State   JobState `json:"state,omitempty"`
+	Details string   `json:"details,omitempty"`
+
+	// Position of the job in its queue, starting at 1, while it is Queued
+	QueuePosition int32 `json:"queuePosition,omitempty"`
+
+	// Time at which the job succeeded or failed
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Training progress parsed from the job logs
+	Progress *JobProgress `json:"progress,omitempty"`
+
+	// Run of the experiment tracker
+	Tracking *JobTrackingStatus `json:"tracking,omitempty"`
+
+	// In-cluster URL of the TensorBoard viewer while it runs
+	TensorBoardURL string `json:"tensorBoardURL,omitempty"`
+
+	// Delivery of the notifications for the current state
+	Notifications []NotificationStatus `json:"notifications,omitempty"`
+
+	// Kind of weights written by the training
+	OutputType OutputType `json:"outputType,omitempty"`
+
+	// Outputs of the postprocess steps
+	Postprocess []PostprocessStepStatus `json:"postprocess,omitempty"`
+
+	// Template the job is based on, as resolved when the job started
+	Template *TemplateStatus `json:"template,omitempty"`
+
+	// Spec of the job merged over its template, as it is run
+	EffectiveSpec *JobSpec `json:"effectiveSpec,omitempty"`
+
+	// Layout of the job volume, locating the model and artifacts
+	Workspace *JobWorkspace `json:"workspace,omitempty"`
+
+	// Digest of the job image, resolved when the job started. The pods run
+	// the image pinned to it.
+	ImageDigest string `json:"imageDigest,omitempty"`
+}
+
+// TemplateStatus identifies the version of the template a job is based on.
+type TemplateStatus struct {
+	// Kind of the template
+	Kind TemplateKind `json:"kind"`
+
+	// Name of the template
+	Name string `json:"name"`
+
+	// Generation of the template merged into the job
+	Generation int64 `json:"generation,omitempty"`
+}
+
+// PostprocessStepStatus is the progress and output of a postprocess step.
+type PostprocessStepStatus struct {
+	// Name of the step
+	Name string `json:"name"`
+
+	// Pending, Running, Succeeded or Failed
+	State string `json:"state,omitempty"`
+
+	// Directory of the output on the job volume
+	Path string `json:"path,omitempty"`
+
+	// Total size of the output in bytes
+	Size int64 `json:"size,omitempty"`
+
+	// Files of the output, relative to its directory
+	Files []string `json:"files,omitempty"`
+}
+
+// OutputType is the kind of weights written by the training.
+type OutputType string
+
+const (
+	// OutputTypeFull is a fully fine-tuned model
+	OutputTypeFull OutputType = "Full"
+	// OutputTypeAdapter is adapter weights only, to load on top of the base model
+	OutputTypeAdapter OutputType = "Adapter"
+	// OutputTypeMerged is adapter weights and the base model with the adapters merged
+	OutputTypeMerged OutputType = "Merged"
+)
+
+// NotificationStatus is the delivery of a notification for a state.
+type NotificationStatus struct {
+	// Name of the notification
+	Name string `json:"name"`
+
+	// State being notified
+	State JobState `json:"state,omitempty"`
+
+	// Whether the endpoint accepted the notification
+	Delivered bool `json:"delivered,omitempty"`
+
+	// Number of delivery attempts
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// Time of the last delivery attempt
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+
+	// Error of the last failed attempt
+	Message string `json:"message,omitempty"`
+}
+
+// JobTrackingStatus identifies the run of the job in its experiment tracker.
+type JobTrackingStatus struct {
+	// Identifier of the run
+	RunID string `json:"runID,omitempty"`
+
+	// Link to the run in the tracker UI
+	RunURL string `json:"runURL,omitempty"`
+}
+
+// JobProgress is the training progress reported by the training logs.
+type JobProgress struct {
+	// Current epoch, starting at 1
+	Epoch int32 `json:"epoch,omitempty"`
+
+	// Number of epochs of the training
+	TotalEpochs int32 `json:"totalEpochs,omitempty"`
+
+	// Number of steps done over all epochs
+	Step int64 `json:"step,omitempty"`
+
+	// Number of steps over all epochs
+	TotalSteps int64 `json:"totalSteps,omitempty"`
+
+	// Latest training loss
+	Loss string `json:"loss,omitempty"`
+
+	// Latest throughput in tokens per second and GPU
+	TokensPerSecond string `json:"tokensPerSecond,omitempty"`
+
+	// Percentage of the training that is done
+	Percent int32 `json:"percent,omitempty"`
+
+	// Estimated time until the training is done
+	ETA string `json:"eta,omitempty"`
+
+	// Last time the progress was read from the logs
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:printcolumn:name="Progress",type=integer,JSONPath=`.status.progress.percent`
+// +kubebuilder:printcolumn:name="Position",type=integer,JSONPath=`.status.queuePosition`,priority=1
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Job is the Schema for the jobs API.
 type Job struct {
@@ -144,3 +1388,86 @@ type JobList struct {
 func init() {
 	SchemeBuilder.Register(&Job{}, &JobList{})
 }
+
+// validateExtraVolumes checks the extra volumes do not replace the volumes of
+// the operator and only mount configuration and claims
+func (js *JobSpec) validateExtraVolumes() error {
+	volumes := map[string]bool{}
+	for _, volume := range js.ExtraVolumes {
+		if errs := validation.IsDNS1123Label(volume.Name); len(errs) > 0 {
+			return fmt.Errorf("invalid extra volume name %s: %s", volume.Name, strings.Join(errs, ", "))
+		}
+		if volumes[volume.Name] || slices.Contains(jobReservedVolumeNames, volume.Name) {
+			return fmt.Errorf("extra volume name %s is already used", volume.Name)
+		}
+		volumes[volume.Name] = true
+		source := volume.VolumeSource
+		if source.ConfigMap == nil && source.Secret == nil && source.PersistentVolumeClaim == nil && source.Projected == nil {
+			return fmt.Errorf("extra volume %s must be a configMap, secret, persistentVolumeClaim or projected volume", volume.Name)
+		}
+	}
+
+	for _, mount := range js.ExtraVolumeMounts {
+		if !volumes[mount.Name] {
+			return fmt.Errorf("extra volume mount %s does not match an extra volume", mount.Name)
+		}
+		mountPath := path.Clean(mount.MountPath)
+		if !path.IsAbs(mountPath) {
+			return fmt.Errorf("extra volume mount %s requires an absolute path", mount.Name)
+		}
+		for _, reserved := range append(slices.Clone(jobReservedMountPaths), js.Workspace.MountPath) {
+			if mountPath == reserved || strings.HasPrefix(mountPath, reserved+"/") {
+				return fmt.Errorf("extra volume mount %s cannot use %s", mount.Name, reserved)
+			}
+		}
+	}
+	return nil
+}
+
+// Layout returns the workspace completed with the default mount path and
+// directories, also when it is not set
+func (ws *JobWorkspace) Layout() JobWorkspace {
+	layout := JobWorkspace{}
+	if ws != nil {
+		layout = *ws
+	}
+	for _, d := range []struct {
+		dir          *string
+		defaultValue string
+	}{
+		{&layout.MountPath, jobDefaultWorkspaceMountPath},
+		{&layout.Model, "model"},
+		{&layout.Data, "data"},
+		{&layout.Output, "output"},
+		{&layout.Checkpoints, "checkpoints"},
+		{&layout.Logs, "logs"},
+	} {
+		if *d.dir == "" {
+			*d.dir = d.defaultValue
+		}
+	}
+	return layout
+}
+
+// validateWorkspace defaults the layout of the job volume and keeps its
+// directories on the volume
+func (js *JobSpec) validateWorkspace() error {
+	layout := js.Workspace.Layout()
+	js.Workspace = &layout
+
+	layout.MountPath = path.Clean(layout.MountPath)
+	if !path.IsAbs(layout.MountPath) || layout.MountPath == "/" {
+		return fmt.Errorf("workspace mount path %s must be an absolute directory", layout.MountPath)
+	}
+	for _, reserved := range jobReservedMountPaths {
+		if layout.MountPath == reserved || strings.HasPrefix(layout.MountPath, reserved+"/") {
+			return fmt.Errorf("workspace mount path cannot use %s", reserved)
+		}
+	}
+	for _, dir := range []string{layout.Model, layout.Data, layout.Output, layout.Checkpoints, layout.Logs} {
+		if !filepath.IsLocal(dir) {
+			return fmt.Errorf("workspace directory %s must be relative to the job volume", dir)
+		}
+	}
+	return nil
+}
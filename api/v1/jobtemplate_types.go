@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TemplateKind is the kind of template an AI Job is based on.
+// +kubebuilder:validation:Enum=JobTemplate;ClusterJobTemplate
+type TemplateKind string
+
+const (
+	// TemplateKindJobTemplate is a template in the namespace of the job
+	TemplateKindJobTemplate TemplateKind = "JobTemplate"
+	// TemplateKindClusterJobTemplate is a template shared by all namespaces
+	TemplateKindClusterJobTemplate TemplateKind = "ClusterJobTemplate"
+)
+
+// TemplateReference selects the template of an AI Job.
+type TemplateReference struct {
+	// Kind of the template, defaults to JobTemplate
+	Kind TemplateKind `json:"kind,omitempty"`
+
+	// Name of the template
+	Name string `json:"name"`
+}
+
+// JobTemplateSpec defines the preset of the AI Jobs based on the template.
+type JobTemplateSpec struct {
+	// Fields of the AI Jobs that do not set them. Lists of named items, like
+	// the notifications and postprocess steps, are merged by name.
+	Template JobSpec `json:"template"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Framework",type=string,JSONPath=`.spec.template.framework`
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.template.image`
+
+// JobTemplate is the Schema for the jobtemplates API.
+type JobTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec JobTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// JobTemplateList contains a list of JobTemplate.
+type JobTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []JobTemplate `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Framework",type=string,JSONPath=`.spec.template.framework`
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.template.image`
+
+// ClusterJobTemplate is the Schema for the clusterjobtemplates API.
+type ClusterJobTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec JobTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterJobTemplateList contains a list of ClusterJobTemplate.
+type ClusterJobTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterJobTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&JobTemplate{}, &JobTemplateList{}, &ClusterJobTemplate{}, &ClusterJobTemplateList{})
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultQueueName is the queue used by jobs that do not set a queue name
+	DefaultQueueName = "default"
+)
+
+// QueueSpec defines the capacity of a Queue.
+type QueueSpec struct {
+	// Maximum number of AI Jobs running at the same time, 0 means unlimited
+	MaxConcurrentJobs int32 `json:"maxConcurrentJobs,omitempty"`
+
+	// Maximum number of GPUs requested by running AI Jobs, 0 means unlimited
+	MaxGPUs int32 `json:"maxGPUs,omitempty"`
+
+	// Name of a Kueue LocalQueue to delegate admission to.
+	// When set, batch jobs are created suspended with the Kueue queue-name label
+	// and the limits above are not enforced by the operator.
+	KueueQueueName string `json:"kueueQueueName,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Max Jobs",type=integer,JSONPath=`.spec.maxConcurrentJobs`
+// +kubebuilder:printcolumn:name="Max GPUs",type=integer,JSONPath=`.spec.maxGPUs`
+
+// Queue is the Schema for the queues API.
+type Queue struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec QueueSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// QueueList contains a list of Queue.
+type QueueList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Queue `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Queue{}, &QueueList{})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+// cancel deletes the AI Job, optionally keeping its volume
+func cancel(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("cancel")
+	keepVolume := fs.Bool("keep-volume", false, "Keep the volume with the model and outputs.")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, err := opts.connect()
+	if err != nil {
+		return err
+	}
+
+	aiJob := &aiv1.Job{}
+	if err := p.client.Get(ctx, client.ObjectKey{Name: name, Namespace: p.namespace}, aiJob); err != nil {
+		return err
+	}
+
+	if *keepVolume && aiJob.Spec.RetentionPolicy != aiv1.RetentionPolicyRetain {
+		patch := client.MergeFrom(aiJob.DeepCopy())
+		aiJob.Spec.RetentionPolicy = aiv1.RetentionPolicyRetain
+		if err := p.client.Patch(ctx, aiJob, patch); err != nil {
+			return fmt.Errorf("failed to retain the volume: %w", err)
+		}
+	}
+
+	if err := p.client.Delete(ctx, aiJob); err != nil {
+		return err
+	}
+
+	fmt.Printf("job.ai.re-cinq.com/%s cancelled\n", name)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+// create creates an AI Job from flags
+func create(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("create")
+	model := fs.String("model", "", "Hugging Face model to fine-tune.")
+	recipe := fs.String("recipe", "full_finetune_single_device", "torchtune recipe to run.")
+	config := fs.String("config", "", "torchtune config of the recipe, required with --recipe or --dataset.")
+	dataset := fs.String("dataset", "", "Hugging Face dataset to train on.")
+	gpus := fs.Int("gpus", 1, "Number of GPUs for the training.")
+	image := fs.String("image", "", "Container image with the training code.")
+	diskSize := fs.Int("disk-size", 0, "Disk size in GB for the model and outputs.")
+	queue := fs.String("queue", "", "Queue that admits the job.")
+	hfToken := fs.String("hf-token", os.Getenv("HF_TOKEN"), "Hugging Face token, defaults to $HF_TOKEN.")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *hfToken == "" {
+		return fmt.Errorf("a Hugging Face token is required, set --hf-token or $HF_TOKEN")
+	}
+
+	// Without a config the operator defaults to its own recipe
+	var command []string
+	recipeSet := false
+	fs.Visit(func(f *flag.Flag) {
+		recipeSet = recipeSet || f.Name == "recipe"
+	})
+	if *config != "" {
+		command = []string{"tune", "run", *recipe, "--config", *config}
+		if *dataset != "" {
+			command = append(command, "dataset.source="+*dataset)
+		}
+	} else if recipeSet || *dataset != "" {
+		return fmt.Errorf("--config is required with --recipe or --dataset")
+	}
+
+	p, err := opts.connect()
+	if err != nil {
+		return err
+	}
+
+	aiJob := &aiv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: p.namespace,
+		},
+		Spec: aiv1.JobSpec{
+			Image:             *image,
+			Model:             *model,
+			DiskSize:          int32(*diskSize),
+			Command:           command,
+			HuggingFaceSecret: *hfToken,
+			GPUs:              int32(*gpus),
+			QueueName:         *queue,
+		},
+	}
+	if err := p.client.Create(ctx, aiJob); err != nil {
+		return err
+	}
+
+	fmt.Printf("job.ai.re-cinq.com/%s created\n", aiJob.Name)
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+// describe prints the AI Job, the conditions of its batch job and the related events
+func describe(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("describe")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, err := opts.connect()
+	if err != nil {
+		return err
+	}
+
+	key := client.ObjectKey{Name: name, Namespace: p.namespace}
+	aiJob := &aiv1.Job{}
+	if err := p.client.Get(ctx, key, aiJob); err != nil {
+		return err
+	}
+
+	// Conditions of the batch job
+	job := &batchv1.Job{}
+	if err := p.client.Get(ctx, key, job); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	// Events of the AI Job, the batch job and its pods
+	events, err := p.events(ctx, name)
+	if err != nil {
+		return err
+	}
+	return printJob(os.Stdout, aiJob, job, events)
+}
+
+// printJob writes the AI Job with the conditions of its batch job and the
+// related events
+func printJob(out io.Writer, aiJob *aiv1.Job, job *batchv1.Job, events []corev1.Event) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", aiJob.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", aiJob.Namespace)
+	fmt.Fprintf(w, "Created:\t%s\n", aiJob.CreationTimestamp.Format(time.RFC3339))
+	if template := aiJob.Status.Template; template != nil {
+		fmt.Fprintf(w, "Template:\t%s/%s, generation %d\n", template.Kind, template.Name, template.Generation)
+	}
+	fmt.Fprintf(w, "Framework:\t%s\n", aiJob.Spec.Framework)
+	fmt.Fprintf(w, "Model:\t%s\n", aiJob.Spec.Model)
+	fmt.Fprintf(w, "Image:\t%s\n", aiJob.Spec.Image)
+	if aiJob.Status.ImageDigest != "" {
+		fmt.Fprintf(w, "Image Digest:\t%s\n", aiJob.Status.ImageDigest)
+	}
+	fmt.Fprintf(w, "GPUs:\t%d\n", aiJob.Spec.GPUs)
+	fmt.Fprintf(w, "Command:\t%s\n", strings.Join(aiJob.Spec.Command, " "))
+	fmt.Fprintf(w, "Queue:\t%s\n", aiJob.Spec.QueueName)
+	if ws := aiJob.Status.Workspace; ws != nil {
+		fmt.Fprintf(w, "Workspace:\t%s, model %s, output %s, logs %s\n", ws.MountPath, ws.Model, ws.Output, ws.Logs)
+	}
+	fmt.Fprintf(w, "State:\t%s\n", aiJob.Status.State)
+	if aiJob.Status.QueuePosition > 0 {
+		fmt.Fprintf(w, "Queue Position:\t%d\n", aiJob.Status.QueuePosition)
+	}
+	if aiJob.Status.Details != "" {
+		fmt.Fprintf(w, "Details:\t%s\n", aiJob.Status.Details)
+	}
+	if progress := aiJob.Status.Progress; progress != nil {
+		fmt.Fprintf(w, "Progress:\t%d%%, epoch %d/%d, step %d/%d\n",
+			progress.Percent, progress.Epoch, progress.TotalEpochs, progress.Step, progress.TotalSteps)
+		fmt.Fprintf(w, "Loss:\t%s\n", progress.Loss)
+		fmt.Fprintf(w, "Tokens/s/GPU:\t%s\n", progress.TokensPerSecond)
+		fmt.Fprintf(w, "ETA:\t%s\n", progress.ETA)
+	}
+	if aiJob.Status.CompletionTime != nil {
+		fmt.Fprintf(w, "Completed:\t%s\n", aiJob.Status.CompletionTime.Format(time.RFC3339))
+	}
+
+	fmt.Fprintln(w, "Conditions:")
+	if len(job.Status.Conditions) == 0 {
+		fmt.Fprintln(w, "  <none>")
+	} else {
+		fmt.Fprintln(w, "  Type\tStatus\tReason\tMessage")
+		for _, condition := range job.Status.Conditions {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+		}
+	}
+
+	fmt.Fprintln(w, "Events:")
+	if len(events) == 0 {
+		fmt.Fprintln(w, "  <none>")
+	} else {
+		fmt.Fprintln(w, "  Type\tReason\tAge\tFrom\tMessage")
+		for _, event := range events {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n",
+				event.Type,
+				event.Reason,
+				duration.HumanDuration(time.Since(eventTime(event))),
+				event.Source.Component,
+				strings.TrimSpace(event.Message),
+			)
+		}
+	}
+
+	return w.Flush()
+}
+
+// events returns the events of the objects named after the AI Job and of its pods, oldest first
+func (p *plugin) events(ctx context.Context, name string) ([]corev1.Event, error) {
+	names := []string{name}
+	pods, err := p.clientset.CoreV1().Pods(p.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{batchv1.JobNameLabel: name}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods.Items {
+		names = append(names, pod.Name)
+	}
+
+	var events []corev1.Event
+	for _, objectName := range names {
+		list, err := p.clientset.CoreV1().Events(p.namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: fields.OneTermEqualSelector("involvedObject.name", objectName).String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, list.Items...)
+	}
+
+	slices.SortFunc(events, func(a, b corev1.Event) int {
+		return eventTime(a).Compare(eventTime(b))
+	})
+	return events, nil
+}
+
+// eventTime returns the last time the event happened
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
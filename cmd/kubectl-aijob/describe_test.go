@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Describe", func() {
+	created := metav1.NewTime(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	It("should print a job that did not start yet", func() {
+		aiJob := &aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "finetune", Namespace: "default", CreationTimestamp: created},
+			Spec:       aiv1.JobSpec{Framework: "torchtune", Model: "Qwen/Qwen2.5-0.5B-Instruct", GPUs: 1},
+			Status:     aiv1.JobStatus{State: aiv1.JobStateQueued, QueuePosition: 3},
+		}
+		out := &bytes.Buffer{}
+		Expect(printJob(out, aiJob, &batchv1.Job{}, nil)).To(Succeed())
+
+		Expect(out.String()).To(HavePrefix("Name:            finetune\n" +
+			"Namespace:       default\n" +
+			"Created:         2025-01-01T12:00:00Z\n" +
+			"Framework:       torchtune\n"))
+		Expect(out.String()).To(ContainSubstring("State:           Queued\nQueue Position:  3\n"))
+		Expect(out.String()).To(HaveSuffix("Conditions:\n  <none>\nEvents:\n  <none>\n"))
+		Expect(out.String()).NotTo(ContainSubstring("Progress:"))
+		Expect(out.String()).NotTo(ContainSubstring("Image Digest:"))
+	})
+
+	It("should print the progress, conditions and events of a running job", func() {
+		aiJob := &aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "finetune", Namespace: "default", CreationTimestamp: created},
+			Spec:       aiv1.JobSpec{Image: "registry.example.com/trainer:v1"},
+			Status: aiv1.JobStatus{
+				State:       aiv1.JobStateRunning,
+				ImageDigest: "sha256:abc",
+				Progress: &aiv1.JobProgress{
+					Percent: 52, Epoch: 1, TotalEpochs: 2, Step: 13, TotalSteps: 25,
+					Loss: "1.2345", TokensPerSecond: "1523.4", ETA: "14s",
+				},
+			},
+		}
+		job := &batchv1.Job{Status: batchv1.JobStatus{Conditions: []batchv1.JobCondition{
+			{Type: batchv1.JobSuspended, Status: corev1.ConditionFalse, Reason: "JobResumed", Message: "Job resumed"},
+		}}}
+		events := []corev1.Event{{
+			Type:          corev1.EventTypeNormal,
+			Reason:        "Started",
+			LastTimestamp: metav1.NewTime(time.Now().Add(-5 * time.Minute)),
+			Source:        corev1.EventSource{Component: "kubelet"},
+			Message:       "Started container finetune\n",
+		}}
+		out := &bytes.Buffer{}
+		Expect(printJob(out, aiJob, job, events)).To(Succeed())
+
+		Expect(out.String()).To(ContainSubstring("Image Digest:  sha256:abc\n"))
+		Expect(out.String()).To(ContainSubstring("Progress:      52%, epoch 1/2, step 13/25\n" +
+			"Loss:          1.2345\n" +
+			"Tokens/s/GPU:  1523.4\n" +
+			"ETA:           14s\n"))
+		Expect(out.String()).To(ContainSubstring("Conditions:\n" +
+			"  Type       Status  Reason      Message\n" +
+			"  Suspended  False   JobResumed  Job resumed\n"))
+		Expect(out.String()).To(HaveSuffix("Events:\n" +
+			"  Type    Reason   Age  From     Message\n" +
+			"  Normal  Started  5m   kubelet  Started container finetune\n"))
+	})
+})
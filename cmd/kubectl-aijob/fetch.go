@@ -0,0 +1,259 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	// Where the helper pod mounts the volume of the AI Job
+	fetchMountPath = "/workspace"
+
+	// How long to wait for the helper pod to start
+	fetchPodTimeout = 5 * time.Minute
+)
+
+// fetch copies files from the volume of the AI Job to the local disk, using a
+// helper pod that mounts the volume read-only
+func fetch(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("fetch")
+	source := fs.String("path", ".", "Path on the volume of the AI Job to copy.")
+	dest := fs.String("dest", ".", "Local directory to copy the files to.")
+	image := fs.String("image", "busybox:1.37", "Image of the helper pod, it must provide tar.")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	// Keep the copy inside the volume
+	cleanSource := path.Clean("/" + *source)
+
+	p, err := opts.connect()
+	if err != nil {
+		return err
+	}
+
+	pod, err := p.createFetchPod(ctx, name, *image)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// The command context may be cancelled already
+		if err := p.clientset.CoreV1().Pods(p.namespace).Delete(context.Background(), pod.Name, metav1.DeleteOptions{}); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to delete helper pod %s: %v\n", pod.Name, err)
+		}
+	}()
+
+	if err := p.waitForPodRunning(ctx, pod.Name); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(*dest, 0o755); err != nil {
+		return err
+	}
+
+	reader, writer := io.Pipe()
+	var stderr bytes.Buffer
+	go func() {
+		err := p.exec(ctx, pod.Name, []string{"tar", "cf", "-", "-C", fetchMountPath + cleanSource, "."}, writer, &stderr)
+		if err != nil && stderr.Len() > 0 {
+			err = fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		_ = writer.CloseWithError(err)
+	}()
+
+	files, err := extractTar(reader, *dest)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("copied %d files from %s to %s\n", files, cleanSource, *dest)
+	return nil
+}
+
+// createFetchPod starts a pod that mounts the volume of the AI Job and waits to be exec'ed into
+func (p *plugin) createFetchPod(ctx context.Context, name, image string) (*corev1.Pod, error) {
+	nonRoot := true
+	noEscalation := false
+	user := int64(65532)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: name + "-fetch-",
+			Namespace:    p.namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       name,
+				"app.kubernetes.io/managed-by": "kubectl-aijob",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			SecurityContext: &corev1.PodSecurityContext{
+				RunAsNonRoot: &nonRoot,
+				RunAsUser:    &user,
+				SeccompProfile: &corev1.SeccompProfile{
+					Type: corev1.SeccompProfileTypeRuntimeDefault,
+				},
+			},
+			Containers: []corev1.Container{
+				{
+					Name:    "fetch",
+					Image:   image,
+					Command: []string{"sleep", "3600"},
+					SecurityContext: &corev1.SecurityContext{
+						AllowPrivilegeEscalation: &noEscalation,
+						Capabilities: &corev1.Capabilities{
+							Drop: []corev1.Capability{"ALL"},
+						},
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      "model",
+							MountPath: fetchMountPath,
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "model",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: name,
+							ReadOnly:  true,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return p.clientset.CoreV1().Pods(p.namespace).Create(ctx, pod, metav1.CreateOptions{})
+}
+
+// waitForPodRunning polls the pod until it runs
+func (p *plugin) waitForPodRunning(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, fetchPodTimeout)
+	defer cancel()
+
+	for {
+		pod, err := p.clientset.CoreV1().Pods(p.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		switch pod.Status.Phase {
+		case corev1.PodRunning:
+			return nil
+		case corev1.PodFailed, corev1.PodSucceeded:
+			return fmt.Errorf("helper pod %s stopped: %s", name, pod.Status.Message)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("helper pod %s did not start, is the volume used by a running job on another node? %w", name, ctx.Err())
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// exec runs a command in the helper pod
+func (p *plugin) exec(ctx context.Context, podName string, command []string, stdout, stderr io.Writer) error {
+	req := p.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(p.namespace).
+		Name(podName).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: "fetch",
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, clientgoscheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(p.config, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	return executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+}
+
+// extractTar writes the tar stream into dest, refusing entries that would
+// escape it. Returns the number of files written.
+func extractTar(r io.Reader, dest string) (int, error) {
+	root, err := filepath.Abs(dest)
+	if err != nil {
+		return 0, err
+	}
+
+	files := 0
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return files, err
+		}
+
+		target := filepath.Join(root, filepath.FromSlash(header.Name))
+		if target != root && !strings.HasPrefix(target, root+string(filepath.Separator)) {
+			return files, fmt.Errorf("refusing to write %s outside of %s", header.Name, dest)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return files, err
+			}
+		case tar.TypeReg:
+			if err := writeFile(target, tr, header.FileInfo().Mode().Perm()); err != nil {
+				return files, err
+			}
+			files++
+		case tar.TypeSymlink:
+			// Only keep links that stay inside the destination
+			link := filepath.Join(filepath.Dir(target), header.Linkname)
+			if filepath.IsAbs(header.Linkname) || !strings.HasPrefix(link, root+string(filepath.Separator)) {
+				fmt.Fprintf(os.Stderr, "skipping symlink %s to %s\n", header.Name, header.Linkname)
+				continue
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil && !os.IsExist(err) {
+				return files, err
+			}
+		}
+	}
+}
+
+// writeFile copies r into a new file at target
+func writeFile(target string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode|0o200)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
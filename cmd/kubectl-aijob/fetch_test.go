@@ -0,0 +1,74 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fetch", func() {
+	// entry is a tar entry, a symlink when link is set
+	type entry struct {
+		name, link, content string
+	}
+
+	archive := func(entries ...entry) *bytes.Buffer {
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		for _, e := range entries {
+			header := &tar.Header{Name: e.name, Mode: 0o644, Typeflag: tar.TypeReg, Size: int64(len(e.content))}
+			if e.link != "" {
+				header = &tar.Header{Name: e.name, Mode: 0o777, Typeflag: tar.TypeSymlink, Linkname: e.link}
+			}
+			Expect(tw.WriteHeader(header)).To(Succeed())
+			_, err := tw.Write([]byte(e.content))
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(tw.Close()).To(Succeed())
+		return buf
+	}
+
+	DescribeTable("extracting the outputs",
+		func(entries []entry, files int, expectErr string, present, absent []string) {
+			dir := GinkgoT().TempDir()
+			dest := filepath.Join(dir, "output")
+			written, err := extractTar(archive(entries...), dest)
+			if expectErr != "" {
+				Expect(err).To(MatchError(ContainSubstring(expectErr)))
+			} else {
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(written).To(Equal(files))
+			for _, name := range present {
+				_, err := os.Lstat(filepath.Join(dest, name))
+				Expect(err).NotTo(HaveOccurred(), name)
+			}
+			for _, name := range absent {
+				_, err := os.Lstat(filepath.Join(dir, name))
+				Expect(os.IsNotExist(err)).To(BeTrue(), name)
+			}
+		},
+		Entry("writes files and nested directories",
+			[]entry{{name: "adapter_config.json", content: "{}"}, {name: "epoch_0/model.safetensors", content: "weights"}},
+			2, "", []string{"adapter_config.json", "epoch_0/model.safetensors"}, nil),
+		Entry("refuses entries climbing out of the destination",
+			[]entry{{name: "../evil", content: "x"}},
+			0, "outside of", nil, []string{"evil"}),
+		Entry("refuses entries climbing out through nested directories",
+			[]entry{{name: "model.bin", content: "x"}, {name: "epoch_0/../../evil", content: "x"}},
+			1, "outside of", []string{"model.bin"}, []string{"evil"}),
+		Entry("writes absolute entries below the destination",
+			[]entry{{name: "/etc/evil", content: "x"}},
+			1, "", []string{"etc/evil"}, []string{"etc"}),
+		Entry("skips symlinks escaping the destination",
+			[]entry{{name: "up", link: "../.."}, {name: "passwd", link: "/etc/passwd"}, {name: "self", link: "."}},
+			0, "", nil, []string{"output/up", "output/passwd", "output/self"}),
+		Entry("keeps symlinks inside the destination",
+			[]entry{{name: "epoch_0/model.safetensors", content: "weights"}, {name: "latest", link: "epoch_0"}},
+			1, "", []string{"latest", "epoch_0/model.safetensors"}, nil),
+	)
+})
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+// list prints the AI Jobs of the namespace with their state
+func list(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("list")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	p, err := opts.connect()
+	if err != nil {
+		return err
+	}
+
+	var aiJobs aiv1.JobList
+	if err := p.client.List(ctx, &aiJobs, client.InNamespace(p.namespace)); err != nil {
+		return err
+	}
+
+	if len(aiJobs.Items) == 0 {
+		fmt.Fprintf(os.Stderr, "No AI Jobs found in %s namespace.\n", p.namespace)
+		return nil
+	}
+
+	return printJobs(os.Stdout, aiJobs.Items)
+}
+
+// printJobs writes the AI Jobs as a table, one line per job
+func printJobs(out io.Writer, aiJobs []aiv1.Job) error {
+	w := tabwriter.NewWriter(out, 0, 8, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tPROGRESS\tETA\tLOSS\tMODEL\tGPUS\tAGE")
+	for _, aiJob := range aiJobs {
+		state := string(aiJob.Status.State)
+		if aiJob.Status.State == aiv1.JobStateQueued {
+			state = fmt.Sprintf("%s (#%d)", state, aiJob.Status.QueuePosition)
+		}
+		if state == "" {
+			state = "Pending"
+		}
+		progress, eta, loss := "-", "-", "-"
+		if current := aiJob.Status.Progress; current != nil {
+			progress = fmt.Sprintf("%d%% (%d/%d)", current.Percent, current.Step, current.TotalSteps)
+			eta, loss = current.ETA, current.Loss
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			aiJob.Name,
+			state,
+			progress,
+			eta,
+			loss,
+			aiJob.Spec.Model,
+			aiJob.Spec.GPUs,
+			duration.HumanDuration(time.Since(aiJob.CreationTimestamp.Time)),
+		)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("List", func() {
+	newJob := func(name string, status aiv1.JobStatus) aiv1.Job {
+		return aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+			},
+			Spec:   aiv1.JobSpec{Model: "Qwen/Qwen2.5-0.5B-Instruct", GPUs: 1},
+			Status: status,
+		}
+	}
+
+	DescribeTable("printing the state of a job",
+		func(status aiv1.JobStatus, expected []string) {
+			out := &bytes.Buffer{}
+			Expect(printJobs(out, []aiv1.Job{newJob("finetune", status)})).To(Succeed())
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect(strings.Fields(lines[0])).To(Equal([]string{"NAME", "STATE", "PROGRESS", "ETA", "LOSS", "MODEL", "GPUS", "AGE"}))
+			Expect(strings.Fields(lines[1])).To(Equal(expected))
+		},
+		Entry("pending before the operator picked it up", aiv1.JobStatus{},
+			[]string{"finetune", "Pending", "-", "-", "-", "Qwen/Qwen2.5-0.5B-Instruct", "1", "120m"}),
+		Entry("queued with its position", aiv1.JobStatus{State: aiv1.JobStateQueued, QueuePosition: 2},
+			[]string{"finetune", "Queued", "(#2)", "-", "-", "-", "Qwen/Qwen2.5-0.5B-Instruct", "1", "120m"}),
+		Entry("running with its progress", aiv1.JobStatus{
+			State:    aiv1.JobStateRunning,
+			Progress: &aiv1.JobProgress{Percent: 52, Step: 13, TotalSteps: 25, ETA: "14s", Loss: "1.2345"},
+		}, []string{"finetune", "Running", "52%", "(13/25)", "14s", "1.2345", "Qwen/Qwen2.5-0.5B-Instruct", "1", "120m"}),
+	)
+
+	It("should align the columns of all jobs", func() {
+		out := &bytes.Buffer{}
+		Expect(printJobs(out, []aiv1.Job{
+			newJob("a", aiv1.JobStatus{State: aiv1.JobStateSucceeded}),
+			newJob("long-job-name", aiv1.JobStatus{State: aiv1.JobStateRunning}),
+		})).To(Succeed())
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		Expect(lines).To(HaveLen(3))
+		column := strings.Index(lines[0], "STATE")
+		Expect(strings.Index(lines[1], "Succeeded")).To(Equal(column))
+		Expect(strings.Index(lines[2], "Running")).To(Equal(column))
+	})
+})
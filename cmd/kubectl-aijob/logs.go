@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// logs prints the logs of the init container and then of the training container
+func logs(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("logs")
+	follow := fs.Bool("f", false, "Follow the logs until the training ends.")
+	name, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	p, err := opts.connect()
+	if err != nil {
+		return err
+	}
+
+	pod, err := p.latestPod(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	for _, container := range []string{name + "-init", name} {
+		if err := p.streamLogs(ctx, pod.Name, container, *follow); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// latestPod returns the most recent pod of the batch job of the AI Job
+func (p *plugin) latestPod(ctx context.Context, name string) (*corev1.Pod, error) {
+	pods, err := p.clientset.CoreV1().Pods(p.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{batchv1.JobNameLabel: name}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pods found for AI Job %s", name)
+	}
+
+	latest := &pods.Items[0]
+	for i := range pods.Items {
+		if pods.Items[i].CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = &pods.Items[i]
+		}
+	}
+	return latest, nil
+}
+
+// streamLogs copies the logs of a container to stdout. When following, it
+// waits for the container to start first.
+func (p *plugin) streamLogs(ctx context.Context, podName, container string, follow bool) error {
+	for {
+		pod, err := p.clientset.CoreV1().Pods(p.namespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if containerStarted(pod, container) {
+			break
+		}
+		if !follow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	stream, err := p.clientset.CoreV1().Pods(p.namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: container,
+		Follow:    follow,
+	}).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stream.Close() }()
+
+	_, err = io.Copy(os.Stdout, stream)
+	return err
+}
+
+// containerStarted reports whether the container is running or has run
+func containerStarted(pod *corev1.Pod, container string) bool {
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.Name == container {
+			return status.State.Running != nil || status.State.Terminated != nil
+		}
+	}
+	return false
+}
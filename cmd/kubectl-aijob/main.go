@@ -0,0 +1,184 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kubectl-aijob is a kubectl plugin to create, watch and fetch AI Jobs.
+// Install it anywhere in the PATH and run it as `kubectl aijob`.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(aiv1.AddToScheme(scheme))
+}
+
+// command is a subcommand of the plugin
+type command struct {
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+// commands is filled in init, as the commands refer to it for their usage
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"create":   {"create NAME --model MODEL [--recipe RECIPE --config CONFIG] [--dataset DATASET] [--gpus N]", create},
+		"list":     {"list", list},
+		"logs":     {"logs NAME [-f]", logs},
+		"describe": {"describe NAME", describe},
+		"cancel":   {"cancel NAME [--keep-volume]", cancel},
+		"fetch":    {"fetch NAME [--path PATH] [--dest DIR]", fetch},
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: kubectl aijob COMMAND [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, name := range []string{"create", "list", "logs", "describe", "cancel", "fetch"} {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'kubectl aijob COMMAND -h' for the flags of a command.")
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.run(ctx, os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// options are the flags shared by every command
+type options struct {
+	kubeconfig string
+	namespace  string
+}
+
+// newFlagSet creates the flag set of a command with the shared flags
+func newFlagSet(name string) (*flag.FlagSet, *options) {
+	opts := &options{}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&opts.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file to use.")
+	fs.StringVar(&opts.namespace, "namespace", "", "Namespace of the AI Job, defaults to the one of the current context.")
+	fs.StringVar(&opts.namespace, "n", "", "Shorthand for --namespace.")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: kubectl aijob %s\n\nFlags:\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs, opts
+}
+
+// parseArgs parses the flags of a command taking one positional NAME argument,
+// which may come before or after the flags
+func parseArgs(fs *flag.FlagSet, args []string) (string, error) {
+	var name string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if name == "" && fs.NArg() > 0 {
+		name = fs.Arg(0)
+	}
+	if name == "" {
+		fs.Usage()
+		return "", fmt.Errorf("the name of the AI Job is required")
+	}
+	return name, nil
+}
+
+// plugin holds the clients used by the commands
+type plugin struct {
+	config    *rest.Config
+	client    client.Client
+	clientset kubernetes.Interface
+	namespace string
+}
+
+// connect loads the kubeconfig the same way kubectl does
+func (o *options) connect() (*plugin, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = o.kubeconfig
+	overrides := &clientcmd.ConfigOverrides{}
+	overrides.Context.Namespace = o.namespace
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	namespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get namespace: %w", err)
+	}
+
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &plugin{
+		config:    config,
+		client:    c,
+		clientset: clientset,
+		namespace: namespace,
+	}, nil
+}
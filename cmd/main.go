@@ -21,6 +21,7 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -28,6 +29,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/certwatcher"
@@ -38,7 +40,10 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/config"
 	"github.com/re-cinq/ai-operator/internal/controller"
+	"github.com/re-cinq/ai-operator/internal/registry"
+	webhookaiv1 "github.com/re-cinq/ai-operator/internal/webhook/v1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -64,6 +69,13 @@ func main() {
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
+	var egress aiv1.JobEgress
+	var hubOffline bool
+	var caBundle aiv1.CABundle
+	var configPath string
+	var pinImageDigests bool
+	var insecureRegistries string
+	var registryTokenHosts string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -81,6 +93,24 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&configPath, "config", "",
+		"Path of the operator configuration file with the defaults and limits of the jobs, reloaded on change.")
+	flag.StringVar(&egress.HuggingFaceEndpoint, "hf-endpoint", "",
+		"URL of a Hugging Face Hub mirror used by the jobs, set as HF_ENDPOINT.")
+	flag.BoolVar(&hubOffline, "hf-hub-offline", false,
+		"If set, the jobs never contact the Hugging Face Hub, setting HF_HUB_OFFLINE.")
+	flag.StringVar(&egress.HTTPProxy, "http-proxy", "", "Proxy of the HTTP requests of the jobs.")
+	flag.StringVar(&egress.HTTPSProxy, "https-proxy", "", "Proxy of the HTTPS requests of the jobs.")
+	flag.StringVar(&egress.NoProxy, "no-proxy", "", "Comma separated hosts and domains the jobs reach without proxy.")
+	flag.StringVar(&caBundle.ConfigMapName, "ca-bundle-configmap", "",
+		"ConfigMap in the namespace of each job holding the CA certificates trusted by the jobs.")
+	flag.StringVar(&caBundle.Key, "ca-bundle-key", "ca.crt", "Key of the certificates in the CA bundle ConfigMap.")
+	flag.BoolVar(&pinImageDigests, "pin-image-digests", true,
+		"If set, the image tag of each job is resolved to a digest when it starts and its pods are pinned to it.")
+	flag.StringVar(&insecureRegistries, "insecure-registries", "",
+		"Comma separated registry hosts reached over plain HTTP when resolving image digests.")
+	flag.StringVar(&registryTokenHosts, "registry-token-hosts", "",
+		"Comma separated token service hosts trusted with the credentials of other registry hosts.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -89,6 +119,17 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if hubOffline {
+		egress.HuggingFaceOffline = &hubOffline
+	}
+	if caBundle.ConfigMapName != "" {
+		egress.CABundle = &caBundle
+	}
+	if err := egress.Validate(); err != nil {
+		setupLog.Error(err, "invalid job egress settings")
+		os.Exit(1)
+	}
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being vulnerable to the HTTP/2 Stream Cancellation and
@@ -202,13 +243,54 @@ func main() {
 		os.Exit(1)
 	}
 
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create clientset")
+		os.Exit(1)
+	}
+
+	var operatorConfig *config.Watcher
+	if configPath != "" {
+		operatorConfig, err = config.NewWatcher(configPath)
+		if err != nil {
+			setupLog.Error(err, "unable to load operator configuration")
+			os.Exit(1)
+		}
+		if err := mgr.Add(operatorConfig); err != nil {
+			setupLog.Error(err, "unable to add configuration watcher to manager")
+			os.Exit(1)
+		}
+	}
+
+	var imageRegistry *registry.Client
+	if pinImageDigests {
+		imageRegistry = &registry.Client{}
+		if insecureRegistries != "" {
+			imageRegistry.Insecure = strings.Split(insecureRegistries, ",")
+		}
+		if registryTokenHosts != "" {
+			imageRegistry.TokenHosts = strings.Split(registryTokenHosts, ",")
+		}
+	}
+
 	if err = (&controller.JobReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Clientset: clientset,
+		Egress:    egress,
+		Config:    operatorConfig,
+		Registry:  imageRegistry,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Job")
 		os.Exit(1)
 	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookaiv1.SetupJobWebhookWithManager(mgr, operatorConfig); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Job")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -0,0 +1,85 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backend adapts AI Jobs to the training frameworks. Each backend
+// registers itself under the name of its framework.
+package backend
+
+import (
+	"fmt"
+	"sync"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+// Backend knows how a training framework downloads models, trains them,
+// stores its checkpoints and reports its progress.
+type Backend interface {
+	// DownloadCommand downloads the model of the job into modelDir
+	DownloadCommand(aiJob aiv1.Job, modelDir string) []string
+
+	// TrainingCommand returns the training command of the job, configured
+	// for its method and tracking
+	TrainingCommand(aiJob aiv1.Job, opts TrainingOptions) []string
+
+	// CheckpointPattern matches the checkpoint directories written to the
+	// output directory, the one with the highest number being the latest.
+	// Empty when the final weights are written to the output directory.
+	CheckpointPattern() string
+
+	// ParseProgress extracts the latest progress from the training logs,
+	// returning nil when the logs contain none
+	ParseProgress(aiJob aiv1.Job, logs string) *aiv1.JobProgress
+}
+
+// TrainingOptions locates the data of the training on the job volume.
+type TrainingOptions struct {
+	// Directory holding the downloaded model
+	ModelDir string
+
+	// Directory receiving the checkpoints and final weights
+	OutputDir string
+
+	// Directory receiving the TensorBoard event files
+	TensorBoardDir string
+
+	// Continue from the last checkpoint of the output directory
+	Resume bool
+}
+
+var (
+	mu       sync.RWMutex
+	backends = map[aiv1.Framework]Backend{}
+)
+
+// Register makes a backend available for a framework, replacing any backend
+// registered before
+func Register(framework aiv1.Framework, backend Backend) {
+	mu.Lock()
+	defer mu.Unlock()
+	backends[framework] = backend
+}
+
+// Get returns the backend of the framework
+func Get(framework aiv1.Framework) (Backend, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	backend, ok := backends[framework]
+	if !ok {
+		return nil, fmt.Errorf("no backend registered for framework %q", framework)
+	}
+	return backend, nil
+}
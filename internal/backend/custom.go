@@ -0,0 +1,31 @@
+package backend
+
+import (
+	"slices"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+func init() {
+	Register(aiv1.FrameworkCustom, custom{})
+}
+
+// custom runs the command of the job unchanged, reporting the progress of
+// trainings based on the Hugging Face Trainer
+type custom struct{}
+
+func (custom) DownloadCommand(aiJob aiv1.Job, modelDir string) []string {
+	return huggingFaceDownload(aiJob, modelDir)
+}
+
+func (custom) TrainingCommand(aiJob aiv1.Job, opts TrainingOptions) []string {
+	return slices.Clone(aiJob.Spec.Command)
+}
+
+func (custom) CheckpointPattern() string {
+	return ""
+}
+
+func (custom) ParseProgress(aiJob aiv1.Job, logs string) *aiv1.JobProgress {
+	return parseTrainerProgress(logs, trainerDefaultEpochs)
+}
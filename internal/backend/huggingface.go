@@ -0,0 +1,255 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+const (
+	// Dataset of the TRL example training
+	trlDefaultDataset = "trl-lib/Capybara"
+
+	// Number of epochs of the Hugging Face Trainer
+	trainerDefaultEpochs = 3
+
+	// Runs the command resuming from the latest checkpoint of the output
+	// directory, given as first argument, when there is one
+	trainerResumeScript = `latest=-1
+for dir in "$0"/checkpoint-*; do
+  [ -d "$dir" ] || continue
+  step="${dir##*-}"
+  if [ "$step" -gt "$latest" ]; then latest="$step"; checkpoint="$dir"; fi
+done
+exec "$@" ${checkpoint:+--resume_from_checkpoint "$checkpoint"}
+`
+)
+
+var (
+	// Progress bar of the Trainer, e.g. " 52%|█████▏    | 13/25 [00:15<00:14,  1.20s/it]"
+	trainerProgressRegexp = regexp.MustCompile(`^\s*(\d+)%\|[^|]*\|\s*(\d+)/(\d+) \[[^<]*<[^,]*,\s*([0-9.]+)(s/it|it/s)`)
+
+	// Metrics logged by the Trainer, e.g. "{'loss': 1.2345, 'grad_norm': 0.5, 'epoch': 0.52}"
+	trainerLossRegexp  = regexp.MustCompile(`'loss': '?([0-9.eE+-]+)`)
+	trainerEpochRegexp = regexp.MustCompile(`'epoch': '?([0-9.]+)`)
+
+	// Modules of the Hugging Face models receiving adapters
+	trainerTargetModules = map[string][]string{
+		"q_proj":      {"q_proj"},
+		"k_proj":      {"k_proj"},
+		"v_proj":      {"v_proj"},
+		"output_proj": {"o_proj"},
+		"mlp":         {"gate_proj", "up_proj", "down_proj"},
+		"output":      {"lm_head"},
+	}
+)
+
+func init() {
+	Register(aiv1.FrameworkTRL, trl{})
+	Register(aiv1.FrameworkAxolotl, axolotl{})
+}
+
+// trl runs the TRL command line, configured through its flags
+type trl struct{}
+
+func (trl) DownloadCommand(aiJob aiv1.Job, modelDir string) []string {
+	return huggingFaceDownload(aiJob, modelDir)
+}
+
+func (trl) TrainingCommand(aiJob aiv1.Job, opts TrainingOptions) []string {
+	command := slices.Clone(aiJob.Spec.Command)
+	if len(command) == 0 {
+		command = []string{"trl", "sft", "--model_name_or_path", opts.ModelDir, "--dataset_name", trlDefaultDataset}
+	}
+	if !slices.Contains(command, "--output_dir") {
+		command = append(command, "--output_dir", opts.OutputDir)
+	}
+
+	if method := aiJob.Spec.Method; method.Adapter() {
+		command = append(command, "--use_peft")
+		switch method.Type {
+		case aiv1.MethodQLoRA:
+			command = append(command, "--load_in_4bit")
+		case aiv1.MethodDoRA:
+			command = append(command, "--use_dora")
+		}
+		if method.Rank > 0 {
+			command = append(command, "--lora_r", strconv.Itoa(int(method.Rank)))
+		}
+		if method.Alpha > 0 {
+			command = append(command, "--lora_alpha", strconv.Itoa(int(method.Alpha)))
+		}
+		if len(method.TargetModules) > 0 {
+			command = append(command, "--lora_target_modules")
+			for _, module := range method.TargetModules {
+				command = append(command, trainerTargetModules[module]...)
+			}
+		}
+	}
+
+	if tracking := aiJob.Spec.Tracking; tracking != nil {
+		command = append(command, "--report_to", trainerReportTo(tracking.Provider), "--run_name", aiJob.Name)
+		if tracking.Provider == aiv1.TrackingProviderTensorBoard {
+			command = append(command, "--logging_dir", opts.TensorBoardDir)
+		}
+	}
+
+	if opts.Resume {
+		return append([]string{"sh", "-c", trainerResumeScript, opts.OutputDir}, command...)
+	}
+	return command
+}
+
+func (trl) CheckpointPattern() string {
+	return ""
+}
+
+func (trl) ParseProgress(aiJob aiv1.Job, logs string) *aiv1.JobProgress {
+	return parseTrainerProgress(logs, trainerEpochs(aiJob.Spec.Command, "--num_train_epochs"))
+}
+
+// axolotl runs an Axolotl config, overriding its settings with flags
+type axolotl struct{}
+
+func (axolotl) DownloadCommand(aiJob aiv1.Job, modelDir string) []string {
+	return huggingFaceDownload(aiJob, modelDir)
+}
+
+func (axolotl) TrainingCommand(aiJob aiv1.Job, opts TrainingOptions) []string {
+	command := append(slices.Clone(aiJob.Spec.Command),
+		"--base_model="+opts.ModelDir,
+		"--output_dir="+opts.OutputDir,
+	)
+
+	if method := aiJob.Spec.Method; method.Adapter() {
+		switch method.Type {
+		case aiv1.MethodQLoRA:
+			command = append(command, "--adapter=qlora", "--load_in_4bit=True")
+		case aiv1.MethodDoRA:
+			command = append(command, "--adapter=lora", "--peft_use_dora=True")
+		default:
+			command = append(command, "--adapter=lora")
+		}
+		if method.Rank > 0 {
+			command = append(command, fmt.Sprintf("--lora_r=%d", method.Rank))
+		}
+		if method.Alpha > 0 {
+			command = append(command, fmt.Sprintf("--lora_alpha=%d", method.Alpha))
+		}
+	}
+
+	if tracking := aiJob.Spec.Tracking; tracking != nil {
+		switch tracking.Provider {
+		case aiv1.TrackingProviderMLflow:
+			command = append(command, "--mlflow_tracking_uri="+tracking.MLflow.TrackingURI, "--mlflow_run_name="+aiJob.Name)
+		case aiv1.TrackingProviderWandB:
+			command = append(command, "--wandb_project="+tracking.WandB.Project, "--wandb_name="+aiJob.Name)
+			if tracking.WandB.Entity != "" {
+				command = append(command, "--wandb_entity="+tracking.WandB.Entity)
+			}
+		case aiv1.TrackingProviderTensorBoard:
+			command = append(command, "--use_tensorboard=True", "--logging_dir="+opts.TensorBoardDir)
+		}
+	}
+
+	if opts.Resume {
+		command = append(command, "--auto_resume_from_checkpoints=True")
+	}
+	return command
+}
+
+func (axolotl) CheckpointPattern() string {
+	return ""
+}
+
+func (axolotl) ParseProgress(aiJob aiv1.Job, logs string) *aiv1.JobProgress {
+	return parseTrainerProgress(logs, trainerEpochs(aiJob.Spec.Command, "--num_epochs"))
+}
+
+// huggingFaceDownload downloads the model with the Hugging Face command line
+func huggingFaceDownload(aiJob aiv1.Job, modelDir string) []string {
+	return []string{"huggingface-cli", "download", aiJob.Spec.Model, "--local-dir", modelDir}
+}
+
+// trainerReportTo returns the Trainer integration of the tracking provider
+func trainerReportTo(provider aiv1.TrackingProvider) string {
+	switch provider {
+	case aiv1.TrackingProviderMLflow:
+		return "mlflow"
+	case aiv1.TrackingProviderWandB:
+		return "wandb"
+	}
+	return "tensorboard"
+}
+
+// trainerEpochs returns the number of epochs set by the flag of the command,
+// or the default of the Trainer
+func trainerEpochs(command []string, flag string) int32 {
+	for i, arg := range command {
+		value, found := strings.CutPrefix(arg, flag+"=")
+		if !found && arg == flag && i+1 < len(command) {
+			value, found = command[i+1], true
+		}
+		if !found {
+			continue
+		}
+		if epochs, err := strconv.ParseFloat(value, 64); err == nil && epochs > 0 {
+			return int32(math.Ceil(epochs))
+		}
+	}
+	return trainerDefaultEpochs
+}
+
+// parseTrainerProgress extracts the latest progress from the logs of the
+// Hugging Face Trainer. The progress bar counts the steps of all epochs, while
+// the logged metrics give the loss and the fractional epoch. Returns nil when
+// the logs contain no progress bar.
+func parseTrainerProgress(logs string, epochs int32) *aiv1.JobProgress {
+	lines := logLines(logs)
+
+	var progress *aiv1.JobProgress
+	var loss, epoch string
+	for i := len(lines) - 1; i >= 0 && (progress == nil || loss == "" || epoch == ""); i-- {
+		if progress == nil {
+			if match := trainerProgressRegexp.FindStringSubmatch(lines[i]); match != nil {
+				step, _ := strconv.ParseInt(match[2], 10, 64)
+				totalSteps, _ := strconv.ParseInt(match[3], 10, 64)
+				speed, _ := strconv.ParseFloat(match[4], 64)
+				progress = &aiv1.JobProgress{
+					Step:        step,
+					TotalSteps:  totalSteps,
+					TotalEpochs: epochs,
+					ETA:         eta(totalSteps-step, secondsPerStep(speed, match[5])),
+				}
+				if totalSteps > 0 {
+					progress.Percent = int32(min(100, step*100/totalSteps))
+				}
+			}
+		}
+		if loss == "" {
+			if match := trainerLossRegexp.FindStringSubmatch(lines[i]); match != nil {
+				loss = match[1]
+			}
+		}
+		if epoch == "" {
+			if match := trainerEpochRegexp.FindStringSubmatch(lines[i]); match != nil {
+				epoch = match[1]
+			}
+		}
+	}
+
+	if progress == nil {
+		return nil
+	}
+	progress.Loss = loss
+	progress.Epoch = 1
+	if done, err := strconv.ParseFloat(epoch, 64); err == nil {
+		progress.Epoch = min(int32(done)+1, max(epochs, 1))
+	}
+	return progress
+}
@@ -0,0 +1,76 @@
+package backend
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Hugging Face backends", func() {
+	It("should configure the TRL adapters and tracking", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{
+			Method: &aiv1.JobMethod{Type: aiv1.MethodQLoRA, Rank: 8, TargetModules: []string{"q_proj", "mlp"}},
+			Tracking: &aiv1.JobTracking{
+				Provider:    aiv1.TrackingProviderTensorBoard,
+				TensorBoard: &aiv1.TensorBoardTracking{LogDir: "tensorboard"},
+			},
+		}}
+		aiJob.Name = "sft"
+
+		command := trl{}.TrainingCommand(aiJob, TrainingOptions{
+			ModelDir:       "/tmp/model",
+			OutputDir:      "/tmp/output",
+			TensorBoardDir: "/tmp/tensorboard",
+		})
+		Expect(command).To(Equal([]string{
+			"trl", "sft", "--model_name_or_path", "/tmp/model", "--dataset_name", "trl-lib/Capybara",
+			"--output_dir", "/tmp/output",
+			"--use_peft", "--load_in_4bit", "--lora_r", "8",
+			"--lora_target_modules", "q_proj", "gate_proj", "up_proj", "down_proj",
+			"--report_to", "tensorboard", "--run_name", "sft", "--logging_dir", "/tmp/tensorboard",
+		}))
+	})
+
+	It("should resume TRL from the latest checkpoint", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{Command: []string{"trl", "sft", "--output_dir", "/tmp/output"}}}
+
+		command := trl{}.TrainingCommand(aiJob, TrainingOptions{OutputDir: "/tmp/output", Resume: true})
+		Expect(command).To(Equal([]string{"sh", "-c", trainerResumeScript, "/tmp/output", "trl", "sft", "--output_dir", "/tmp/output"}))
+	})
+
+	It("should override the Axolotl config", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{
+			Command: []string{"axolotl", "train", "config.yaml"},
+			Method:  &aiv1.JobMethod{Type: aiv1.MethodDoRA},
+		}}
+
+		command := axolotl{}.TrainingCommand(aiJob, TrainingOptions{ModelDir: "/tmp/model", OutputDir: "/tmp/output", Resume: true})
+		Expect(command).To(Equal([]string{
+			"axolotl", "train", "config.yaml",
+			"--base_model=/tmp/model", "--output_dir=/tmp/output",
+			"--adapter=lora", "--peft_use_dora=True",
+			"--auto_resume_from_checkpoints=True",
+		}))
+	})
+
+	It("should parse the progress of the Trainer", func() {
+		logs := "{'loss': 1.4, 'grad_norm': 0.5, 'learning_rate': 2e-05, 'epoch': 0.8}\n" +
+			" 40%|████      | 40/100 [00:40<01:00,  1.00it/s]\r" +
+			" 52%|█████▏    | 52/100 [00:52<00:48,  1.00it/s]"
+
+		progress := parseTrainerProgress(logs, trainerEpochs([]string{"--num_train_epochs", "2"}, "--num_train_epochs"))
+		Expect(progress).NotTo(BeNil())
+		Expect(progress.Epoch).To(Equal(int32(1)))
+		Expect(progress.TotalEpochs).To(Equal(int32(2)))
+		Expect(progress.Step).To(Equal(int64(52)))
+		Expect(progress.TotalSteps).To(Equal(int64(100)))
+		Expect(progress.Loss).To(Equal("1.4"))
+		Expect(progress.Percent).To(Equal(int32(52)))
+		Expect(progress.ETA).To(Equal("48s"))
+	})
+
+	It("should ignore logs without a progress bar", func() {
+		Expect(parseTrainerProgress("Downloading model...\n", 3)).To(BeNil())
+	})
+})
@@ -0,0 +1,251 @@
+package backend
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+const (
+	// torchtune metric loggers
+	torchtuneMLflowLogger      = "torchtune.training.metric_logging.MLFlowLogger"
+	torchtuneWandBLogger       = "torchtune.training.metric_logging.WandBLogger"
+	torchtuneTensorBoardLogger = "torchtune.training.metric_logging.TensorBoardLogger"
+
+	// torchtune override that restarts the training from the last checkpoint
+	torchtuneResumeArgument = "resume_from_checkpoint=True"
+)
+
+var (
+	// torchtune progress bar, e.g. "1|13|Loss: 1.2345:  52%|█████▏    | 13/25 [00:15<00:14,  1.20s/it]"
+	tqdmProgressRegexp = regexp.MustCompile(`(\d+)\|(\d+)\|Loss: ([^:]+):\s*\d+%\|[^|]*\|\s*(\d+)/(\d+) \[[^<]*<[^,]*,\s*([0-9.]+)(s/it|it/s)`)
+
+	// torchtune metric logger line, e.g. "Step 13 | loss:1.2345 lr:2e-05 tokens_per_second_per_gpu:1523.4"
+	metricProgressRegexp = regexp.MustCompile(`Step (\d+) \|.*?tokens_per_second_per_gpu:([0-9.eE+-]+)`)
+
+	// Override of the number of epochs in the training command
+	epochsArgumentRegexp = regexp.MustCompile(`^epochs=(\d+)$`)
+)
+
+func init() {
+	Register(aiv1.FrameworkTorchtune, torchtune{})
+}
+
+// torchtune runs torchtune recipes, configured through config overrides
+type torchtune struct{}
+
+func (torchtune) DownloadCommand(aiJob aiv1.Job, modelDir string) []string {
+	return []string{"tune", "download", aiJob.Spec.Model, "--output-dir", modelDir}
+}
+
+func (torchtune) TrainingCommand(aiJob aiv1.Job, opts TrainingOptions) []string {
+	command := slices.Clone(aiJob.Spec.Command)
+	if len(command) == 0 {
+		command = []string{
+			"tune",
+			"run",
+			"full_finetune_single_device",
+			"-r=3",
+			"--config",
+			"qwen2_5/0.5B_full_single_device",
+			// The Qwen2.5 configs expect the model under /tmp
+			"checkpointer.checkpoint_dir=" + opts.ModelDir,
+			"tokenizer.path=" + path.Join(opts.ModelDir, "vocab.json"),
+			"tokenizer.merges_file=" + path.Join(opts.ModelDir, "merges.txt"),
+		}
+		if aiJob.Spec.Method.Adapter() {
+			command[2] = "lora_finetune_single_device"
+			command[5] = "qwen2_5/0.5B_lora_single_device"
+		}
+	}
+
+	command = append(command, torchtuneMethodArguments(aiJob.Spec.Method)...)
+	command = append(command, torchtuneTrackingArguments(aiJob.Spec.Tracking, opts)...)
+	if !slices.ContainsFunc(command, func(arg string) bool { return strings.HasPrefix(arg, "output_dir=") }) {
+		command = append(command, "output_dir="+opts.OutputDir)
+	}
+	if opts.Resume {
+		command = append(command, torchtuneResumeArgument)
+	}
+	return command
+}
+
+func (torchtune) CheckpointPattern() string {
+	return "epoch_*"
+}
+
+func (torchtune) ParseProgress(aiJob aiv1.Job, logs string) *aiv1.JobProgress {
+	return parseTorchtuneProgress(logs, totalEpochs(aiJob.Spec.Command))
+}
+
+// torchtuneMethodArguments returns the overrides configuring the adapters of
+// the fine-tuning method
+func torchtuneMethodArguments(method *aiv1.JobMethod) []string {
+	if !method.Adapter() {
+		return nil
+	}
+
+	var args []string
+	switch method.Type {
+	case aiv1.MethodQLoRA:
+		args = append(args, "model.quantize_base=True")
+	case aiv1.MethodDoRA:
+		args = append(args, "model.use_dora=True")
+	}
+	if method.Rank > 0 {
+		args = append(args, fmt.Sprintf("model.lora_rank=%d", method.Rank))
+	}
+	if method.Alpha > 0 {
+		args = append(args, fmt.Sprintf("model.lora_alpha=%d", method.Alpha))
+	}
+	if len(method.TargetModules) > 0 {
+		var attention []string
+		for _, module := range method.TargetModules {
+			if strings.HasSuffix(module, "_proj") {
+				attention = append(attention, module)
+			}
+		}
+		args = append(args,
+			fmt.Sprintf("model.lora_attn_modules=[%s]", strings.Join(attention, ",")),
+			fmt.Sprintf("model.apply_lora_to_mlp=%s", pythonBool(slices.Contains(method.TargetModules, "mlp"))),
+			fmt.Sprintf("model.apply_lora_to_output=%s", pythonBool(slices.Contains(method.TargetModules, "output"))),
+		)
+	}
+
+	// The checkpointer merges the adapters into the base weights unless told otherwise
+	args = append(args, "save_adapter_weights_only="+pythonBool(!method.Merge))
+	return args
+}
+
+// torchtuneTrackingArguments selects the metric logger of the experiment tracker
+func torchtuneTrackingArguments(tracking *aiv1.JobTracking, opts TrainingOptions) []string {
+	if tracking == nil {
+		return nil
+	}
+
+	switch tracking.Provider {
+	case aiv1.TrackingProviderMLflow:
+		return []string{"metric_logger._component_=" + torchtuneMLflowLogger}
+	case aiv1.TrackingProviderWandB:
+		args := []string{
+			"metric_logger._component_=" + torchtuneWandBLogger,
+			"metric_logger.project=" + tracking.WandB.Project,
+		}
+		if tracking.WandB.Entity != "" {
+			args = append(args, "metric_logger.entity="+tracking.WandB.Entity)
+		}
+		return args
+	case aiv1.TrackingProviderTensorBoard:
+		return []string{
+			"metric_logger._component_=" + torchtuneTensorBoardLogger,
+			"metric_logger.log_dir=" + opts.TensorBoardDir,
+		}
+	}
+	return nil
+}
+
+// totalEpochs returns the number of epochs set in the training command,
+// falling back to the single epoch used by the torchtune configs
+func totalEpochs(command []string) int32 {
+	for _, arg := range command {
+		if match := epochsArgumentRegexp.FindStringSubmatch(arg); match != nil {
+			if epochs, err := strconv.ParseInt(match[1], 10, 32); err == nil && epochs > 0 {
+				return int32(epochs)
+			}
+		}
+	}
+	return 1
+}
+
+// parseTorchtuneProgress extracts the latest progress from torchtune logs. The
+// progress bar gives the epoch, steps, loss and speed, while the metric logger
+// gives the throughput. Returns nil when the logs contain no progress bar.
+func parseTorchtuneProgress(logs string, epochs int32) *aiv1.JobProgress {
+	lines := logLines(logs)
+
+	var progress *aiv1.JobProgress
+	var tokensPerSecond string
+	for i := len(lines) - 1; i >= 0 && (progress == nil || tokensPerSecond == ""); i-- {
+		if progress == nil {
+			if match := tqdmProgressRegexp.FindStringSubmatch(lines[i]); match != nil {
+				progress = newTorchtuneProgress(match, epochs)
+			}
+		}
+		if tokensPerSecond == "" {
+			if match := metricProgressRegexp.FindStringSubmatch(lines[i]); match != nil {
+				tokensPerSecond = match[2]
+			}
+		}
+	}
+
+	if progress != nil {
+		progress.TokensPerSecond = tokensPerSecond
+	}
+	return progress
+}
+
+// newTorchtuneProgress builds the progress from a match of tqdmProgressRegexp
+func newTorchtuneProgress(match []string, epochs int32) *aiv1.JobProgress {
+	epoch, _ := strconv.ParseInt(match[1], 10, 32)
+	step, _ := strconv.ParseInt(match[2], 10, 64)
+	epochStep, _ := strconv.ParseInt(match[4], 10, 64)
+	stepsPerEpoch, _ := strconv.ParseInt(match[5], 10, 64)
+	speed, _ := strconv.ParseFloat(match[6], 64)
+
+	// Resumed or extended trainings may run past the configured epochs
+	totalEpochs := max(epochs, int32(epoch))
+	progress := &aiv1.JobProgress{
+		Epoch:       int32(epoch),
+		TotalEpochs: totalEpochs,
+		Step:        step,
+		TotalSteps:  stepsPerEpoch * int64(totalEpochs),
+		Loss:        strings.TrimSpace(match[3]),
+	}
+
+	done := (epoch-1)*stepsPerEpoch + epochStep
+	if progress.TotalSteps > 0 {
+		progress.Percent = int32(min(100, done*100/progress.TotalSteps))
+	}
+	progress.ETA = eta(progress.TotalSteps-done, secondsPerStep(speed, match[7]))
+
+	return progress
+}
+
+// pythonBool formats a boolean for a Python command line
+func pythonBool(value bool) string {
+	if value {
+		return "True"
+	}
+	return "False"
+}
+
+// logLines splits the logs into lines, progress bars redraw the same line
+// with carriage returns
+func logLines(logs string) []string {
+	return strings.FieldsFunc(logs, func(r rune) bool {
+		return r == '\n' || r == '\r'
+	})
+}
+
+// secondsPerStep converts the speed of a tqdm progress bar
+func secondsPerStep(speed float64, unit string) float64 {
+	if unit == "it/s" && speed > 0 {
+		return 1 / speed
+	}
+	return speed
+}
+
+// eta formats the time needed for the remaining steps
+func eta(remaining int64, secondsPerStep float64) string {
+	if remaining < 0 || secondsPerStep <= 0 {
+		return ""
+	}
+	duration := time.Duration(float64(remaining) * secondsPerStep * float64(time.Second))
+	return duration.Round(time.Second).String()
+}
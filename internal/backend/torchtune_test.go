@@ -0,0 +1,91 @@
+package backend
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("torchtune backend", func() {
+	It("should configure quantized adapters", func() {
+		method := &aiv1.JobMethod{
+			Type:          aiv1.MethodQLoRA,
+			Rank:          16,
+			Alpha:         32,
+			TargetModules: []string{"q_proj", "v_proj", "mlp"},
+		}
+
+		Expect(torchtuneMethodArguments(method)).To(Equal([]string{
+			"model.quantize_base=True",
+			"model.lora_rank=16",
+			"model.lora_alpha=32",
+			"model.lora_attn_modules=[q_proj,v_proj]",
+			"model.apply_lora_to_mlp=True",
+			"model.apply_lora_to_output=False",
+			"save_adapter_weights_only=True",
+		}))
+	})
+
+	It("should merge the adapters on request", func() {
+		method := &aiv1.JobMethod{Type: aiv1.MethodLoRA, Merge: true}
+		Expect(torchtuneMethodArguments(method)).To(Equal([]string{"save_adapter_weights_only=False"}))
+		Expect(torchtuneMethodArguments(nil)).To(BeEmpty())
+	})
+
+	It("should select the LoRA recipe by default", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{Method: &aiv1.JobMethod{Type: aiv1.MethodDoRA}}}
+
+		command := torchtune{}.TrainingCommand(aiJob, TrainingOptions{
+			ModelDir:  "/workspace/model",
+			OutputDir: "/tmp/output",
+			Resume:    true,
+		})
+		Expect(command).To(ContainElements(
+			"lora_finetune_single_device",
+			"qwen2_5/0.5B_lora_single_device",
+			"model.use_dora=True",
+			"output_dir=/tmp/output",
+			"checkpointer.checkpoint_dir=/workspace/model",
+			"tokenizer.path=/workspace/model/vocab.json",
+			"tokenizer.merges_file=/workspace/model/merges.txt",
+			"resume_from_checkpoint=True",
+		))
+	})
+
+	It("should keep the output directory of the command and leave its model alone", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{Command: []string{
+			"tune", "run", "lora_finetune_single_device", "--config", "llama3_2/1B_lora_single_device",
+			"output_dir=/tmp/mine",
+		}}}
+
+		command := torchtune{}.TrainingCommand(aiJob, TrainingOptions{ModelDir: "/workspace/model", OutputDir: "/tmp/output"})
+		Expect(command).To(Equal([]string{
+			"tune", "run", "lora_finetune_single_device", "--config", "llama3_2/1B_lora_single_device",
+			"output_dir=/tmp/mine",
+		}))
+		Expect(aiJob.Spec.Command).To(HaveLen(6))
+	})
+
+	It("should parse the latest progress bar and metrics from the logs", func() {
+		logs := "Step 12 | loss:1.3 lr:2e-05 tokens_per_second_per_gpu:1523.4\n" +
+			"1|12|Loss: 1.3000:  48%|████▊     | 12/25 [00:15<00:15,  1.20s/it]\r" +
+			"1|13|Loss: 1.2000:  52%|█████▏    | 13/25 [00:16<00:14,  1.20s/it]"
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{Command: []string{"tune", "run", "epochs=2"}}}
+
+		progress := torchtune{}.ParseProgress(aiJob, logs)
+		Expect(progress).NotTo(BeNil())
+		Expect(progress.Epoch).To(Equal(int32(1)))
+		Expect(progress.TotalEpochs).To(Equal(int32(2)))
+		Expect(progress.Step).To(Equal(int64(13)))
+		Expect(progress.TotalSteps).To(Equal(int64(50)))
+		Expect(progress.Loss).To(Equal("1.2000"))
+		Expect(progress.TokensPerSecond).To(Equal("1523.4"))
+		Expect(progress.Percent).To(Equal(int32(26)))
+		Expect(progress.ETA).To(Equal("44s"))
+	})
+
+	It("should ignore logs without a progress bar", func() {
+		Expect(parseTorchtuneProgress("Downloading model...\n", 1)).To(BeNil())
+	})
+})
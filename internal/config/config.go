@@ -0,0 +1,225 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config loads the cluster-wide settings of the operator from a file,
+// usually a mounted ConfigMap, and reloads them when the file changes.
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/registry"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Config holds the cluster-wide settings of the operator.
+type Config struct {
+	// Values of the AI Job fields left empty
+	Defaults Defaults `json:"defaults,omitempty"`
+
+	// Prefixes of the images the jobs may run, like a registry host followed
+	// by a slash. All images are allowed when empty.
+	AllowedImages []string `json:"allowedImages,omitempty"`
+
+	// Largest disk size in GB a job may request, unlimited when zero
+	MaxDiskSize int32 `json:"maxDiskSize,omitempty"`
+
+	// Scheduling constraints of the training and postprocess pods
+	Scheduling Scheduling `json:"scheduling,omitempty"`
+
+	// Let the training pods of jobs run in the network namespace of the node
+	AllowHostNetwork bool `json:"allowHostNetwork,omitempty"`
+
+	// Rules the Roles of the job ServiceAccounts may grant. Each rule of a
+	// job must be covered by one of them, jobs cannot grant rules when empty.
+	AllowedServiceAccountRules []rbacv1.PolicyRule `json:"allowedServiceAccountRules,omitempty"`
+}
+
+// Defaults are the values of the AI Job fields left empty. The built-in
+// defaults apply to the fields that are not set here either.
+type Defaults struct {
+	// Container image of each framework
+	Images map[aiv1.Framework]string `json:"images,omitempty"`
+
+	// Model to train
+	Model string `json:"model,omitempty"`
+
+	// Disk size in GB
+	DiskSize int32 `json:"diskSize,omitempty"`
+
+	// Storage class of the job volumes
+	StorageClassName string `json:"storageClassName,omitempty"`
+
+	// Runtime class of the training pods
+	RuntimeClassName string `json:"runtimeClassName,omitempty"`
+}
+
+// Scheduling constrains where the pods of the jobs run.
+type Scheduling struct {
+	NodeSelector      map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations       []corev1.Toleration `json:"tolerations,omitempty"`
+	Affinity          *corev1.Affinity    `json:"affinity,omitempty"`
+	PriorityClassName string              `json:"priorityClassName,omitempty"`
+}
+
+// Parse decodes and validates a YAML or JSON configuration
+func Parse(data []byte) (*Config, error) {
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if config.Defaults.DiskSize < 0 || config.MaxDiskSize < 0 {
+		return nil, fmt.Errorf("disk sizes must not be negative")
+	}
+	if config.MaxDiskSize > 0 && config.Defaults.DiskSize > config.MaxDiskSize {
+		return nil, fmt.Errorf("the default disk size %d exceeds the maximum of %d", config.Defaults.DiskSize, config.MaxDiskSize)
+	}
+	for framework, image := range config.Defaults.Images {
+		if !config.ImageAllowed(image) {
+			return nil, fmt.Errorf("the default %s image %s is not allowed", framework, image)
+		}
+	}
+	return config, nil
+}
+
+// ApplyDefaults sets the empty fields of the spec to the configured
+// defaults. Called before the spec is validated, which sets the built-in
+// defaults of the remaining fields.
+func (c *Config) ApplyDefaults(spec *aiv1.JobSpec) {
+	framework := spec.Framework
+	if framework == "" {
+		framework = aiv1.FrameworkTorchtune
+	}
+	if spec.Image == "" {
+		spec.Image = c.Defaults.Images[framework]
+	}
+	if spec.Model == "" {
+		spec.Model = c.Defaults.Model
+	}
+	if spec.DiskSize <= 0 {
+		spec.DiskSize = c.Defaults.DiskSize
+	}
+	if spec.StorageClassName == "" {
+		spec.StorageClassName = c.Defaults.StorageClassName
+	}
+	if spec.RuntimeClassName == "" {
+		spec.RuntimeClassName = c.Defaults.RuntimeClassName
+	}
+}
+
+// Check rejects validated specs running images that are not allowed,
+// requesting too much disk, using the host network or granting rules that
+// are not allowed
+func (c *Config) Check(spec aiv1.JobSpec) error {
+	if c.MaxDiskSize > 0 && spec.DiskSize > c.MaxDiskSize {
+		return fmt.Errorf("disk size %d exceeds the maximum of %d", spec.DiskSize, c.MaxDiskSize)
+	}
+
+	if !c.AllowHostNetwork && spec.Networking != nil && spec.Networking.HostNetwork {
+		return fmt.Errorf("host network is not allowed by the operator configuration")
+	}
+
+	for _, image := range spec.Images() {
+		if image != "" && !c.ImageAllowed(image) {
+			return fmt.Errorf("image %s is not allowed", image)
+		}
+	}
+
+	return c.CheckServiceAccountRules(spec)
+}
+
+// CheckServiceAccountRules rejects specs whose ServiceAccount Role grants
+// rules that are not allowed, which the admission webhook also checks
+func (c *Config) CheckServiceAccountRules(spec aiv1.JobSpec) error {
+	if spec.ServiceAccount == nil {
+		return nil
+	}
+	for i, rule := range spec.ServiceAccount.Rules {
+		if !slices.ContainsFunc(c.AllowedServiceAccountRules, func(allowed rbacv1.PolicyRule) bool {
+			return ruleCovers(allowed, rule)
+		}) {
+			return fmt.Errorf("serviceAccount rule %d is not allowed by the operator configuration", i)
+		}
+	}
+	return nil
+}
+
+// ruleCovers reports whether the allowed rule grants everything the rule
+// does. Wildcards of the rule are only covered by wildcards.
+func ruleCovers(allowed, rule rbacv1.PolicyRule) bool {
+	covers := func(allowed, values []string) bool {
+		return len(values) > 0 && !slices.ContainsFunc(values, func(value string) bool {
+			return !slices.Contains(allowed, rbacv1.ResourceAll) && !slices.Contains(allowed, value)
+		})
+	}
+	if len(rule.NonResourceURLs) > 0 {
+		return false
+	}
+	if len(allowed.ResourceNames) > 0 && !covers(allowed.ResourceNames, rule.ResourceNames) {
+		return false
+	}
+	return covers(allowed.APIGroups, rule.APIGroups) &&
+		covers(allowed.Resources, rule.Resources) &&
+		covers(allowed.Verbs, rule.Verbs)
+}
+
+// ImageAllowed reports whether the image starts with an allowed prefix
+func (c *Config) ImageAllowed(image string) bool {
+	return len(c.AllowedImages) == 0 || slices.ContainsFunc(c.AllowedImages, func(prefix string) bool {
+		return registry.HasPrefix(image, prefix)
+	})
+}
+
+// Schedule adds the scheduling constraints to the pod. The constraints of the
+// pod itself are kept.
+func (c *Config) Schedule(pod *corev1.PodSpec) {
+	scheduling := c.Scheduling.DeepCopy()
+	for key, value := range scheduling.NodeSelector {
+		if pod.NodeSelector == nil {
+			pod.NodeSelector = map[string]string{}
+		}
+		if _, ok := pod.NodeSelector[key]; !ok {
+			pod.NodeSelector[key] = value
+		}
+	}
+	pod.Tolerations = append(pod.Tolerations, scheduling.Tolerations...)
+	if pod.Affinity == nil {
+		pod.Affinity = scheduling.Affinity
+	}
+	if pod.PriorityClassName == "" {
+		pod.PriorityClassName = scheduling.PriorityClassName
+	}
+}
+
+// DeepCopy returns a copy sharing no data with the scheduling
+func (s *Scheduling) DeepCopy() *Scheduling {
+	out := &Scheduling{
+		NodeSelector:      maps.Clone(s.NodeSelector),
+		PriorityClassName: s.PriorityClassName,
+	}
+	for _, toleration := range s.Tolerations {
+		out.Tolerations = append(out.Tolerations, *toleration.DeepCopy())
+	}
+	if s.Affinity != nil {
+		out.Affinity = s.Affinity.DeepCopy()
+	}
+	return out
+}
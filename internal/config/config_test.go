@@ -0,0 +1,166 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+const testConfig = `
+defaults:
+  images:
+    torchtune: registry.internal/torchtune:0.6
+  diskSize: 100
+  storageClassName: fast
+  runtimeClassName: nvidia-cdi
+allowedImages:
+  - registry.internal/
+maxDiskSize: 200
+scheduling:
+  nodeSelector:
+    nvidia.com/gpu.present: "true"
+  tolerations:
+    - key: nvidia.com/gpu
+      operator: Exists
+      effect: NoSchedule
+`
+
+var _ = Describe("Config", func() {
+	It("should default the empty fields of the spec", func() {
+		config, err := Parse([]byte(testConfig))
+		Expect(err).NotTo(HaveOccurred())
+
+		spec := aiv1.JobSpec{HuggingFaceSecret: "token", StorageClassName: "local-path"}
+		config.ApplyDefaults(&spec)
+		Expect(spec.Validate()).To(Succeed())
+		Expect(spec.Image).To(Equal("registry.internal/torchtune:0.6"))
+		Expect(spec.DiskSize).To(Equal(int32(100)))
+		Expect(spec.StorageClassName).To(Equal("local-path"))
+		Expect(spec.RuntimeClassName).To(Equal("nvidia-cdi"))
+		Expect(spec.Model).To(Equal("Qwen/Qwen2.5-0.5B-Instruct"))
+		Expect(config.Check(spec)).To(Succeed())
+	})
+
+	It("should only let jobs grant the allowed service account rules", func() {
+		config, err := Parse([]byte(`
+allowedServiceAccountRules:
+  - apiGroups: [""]
+    resources: [configmaps, secrets]
+    verbs: [get, list]
+    resourceNames: [training-config, training-credentials]
+  - apiGroups: [batch]
+    resources: ["*"]
+    verbs: [get]
+`))
+		Expect(err).NotTo(HaveOccurred())
+
+		spec := aiv1.JobSpec{ServiceAccount: &aiv1.JobServiceAccount{}}
+		Expect(config.Check(spec)).To(Succeed())
+		Expect((&Config{}).Check(spec)).To(Succeed())
+
+		for _, rule := range []rbacv1.PolicyRule{
+			{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}, ResourceNames: []string{"training-config"}},
+			{APIGroups: []string{"batch"}, Resources: []string{"jobs", "cronjobs"}, Verbs: []string{"get"}},
+		} {
+			spec.ServiceAccount.Rules = []rbacv1.PolicyRule{rule}
+			Expect(config.Check(spec)).To(Succeed(), "%v", rule)
+		}
+
+		for _, rule := range []rbacv1.PolicyRule{
+			// Any name of an allowed resource
+			{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get"}},
+			// Verbs that are not allowed
+			{APIGroups: []string{"batch"}, Resources: []string{"jobs"}, Verbs: []string{"get", "delete"}},
+			{APIGroups: []string{"batch"}, Resources: []string{"jobs"}, Verbs: []string{"*"}},
+			// Other groups and resources
+			{APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"roles"}, Verbs: []string{"get"}},
+			{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}, ResourceNames: []string{"training-config"}},
+			// Rules without any resource
+			{APIGroups: []string{"batch"}, Verbs: []string{"get"}},
+		} {
+			spec.ServiceAccount.Rules = []rbacv1.PolicyRule{rule}
+			Expect(config.Check(spec)).To(MatchError(ContainSubstring("serviceAccount rule 0 is not allowed")), "%v", rule)
+		}
+		Expect((&Config{}).Check(spec)).NotTo(Succeed())
+	})
+
+	It("should reject images and disks outside the limits", func() {
+		config, err := Parse([]byte(testConfig))
+		Expect(err).NotTo(HaveOccurred())
+
+		spec := aiv1.JobSpec{HuggingFaceSecret: "token", DiskSize: 300}
+		config.ApplyDefaults(&spec)
+		Expect(spec.Validate()).To(Succeed())
+		Expect(config.Check(spec)).To(MatchError(ContainSubstring("exceeds the maximum")))
+
+		spec.DiskSize = 50
+		spec.Postprocess = []aiv1.PostprocessStep{{Name: "gguf", Type: aiv1.PostprocessConvertGGUF}}
+		Expect(spec.Validate()).To(Succeed())
+		Expect(config.Check(spec)).To(MatchError("image ghcr.io/ggml-org/llama.cpp:full is not allowed"))
+	})
+
+	It("should only allow images under the prefix, not lookalike hosts", func() {
+		config := &Config{AllowedImages: []string{"registry.example.com", "ghcr.io/org/trainer"}}
+		Expect(config.ImageAllowed("registry.example.com/team/trainer:v1")).To(BeTrue())
+		Expect(config.ImageAllowed("ghcr.io/org/trainer:v1")).To(BeTrue())
+		Expect(config.ImageAllowed("ghcr.io/org/trainer@sha256:0123")).To(BeTrue())
+		Expect(config.ImageAllowed("registry.example.com.evil.io/team/trainer:v1")).To(BeFalse())
+		Expect(config.ImageAllowed("ghcr.io/org/trainer-evil:v1")).To(BeFalse())
+	})
+
+	It("should only let jobs use the host network when allowed", func() {
+		spec := aiv1.JobSpec{HuggingFaceSecret: "token", Networking: &aiv1.JobNetworking{HostNetwork: true}}
+		Expect(spec.Validate()).To(Succeed())
+		Expect((&Config{}).Check(spec)).To(MatchError(ContainSubstring("host network is not allowed")))
+		Expect((&Config{AllowHostNetwork: true}).Check(spec)).To(Succeed())
+	})
+
+	It("should reject invalid configurations", func() {
+		_, err := Parse([]byte("maxDiskSize: 10\ndefaults:\n  diskSize: 20\n"))
+		Expect(err).To(MatchError(ContainSubstring("exceeds the maximum")))
+		_, err = Parse([]byte("unknown: true\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should keep the scheduling constraints of the pod", func() {
+		config, err := Parse([]byte(testConfig))
+		Expect(err).NotTo(HaveOccurred())
+
+		pod := &corev1.PodSpec{NodeSelector: map[string]string{"nvidia.com/gpu.present": "false"}}
+		config.Schedule(pod)
+		Expect(pod.NodeSelector).To(Equal(map[string]string{"nvidia.com/gpu.present": "false"}))
+		Expect(pod.Tolerations).To(HaveLen(1))
+
+		pod.Tolerations[0].Key = "changed"
+		Expect(config.Scheduling.Tolerations[0].Key).To(Equal("nvidia.com/gpu"))
+	})
+
+	It("should reload valid changes of the file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "config.yaml")
+		Expect(os.WriteFile(path, []byte(testConfig), 0o600)).To(Succeed())
+
+		watcher, err := NewWatcher(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(watcher.Get().MaxDiskSize).To(Equal(int32(200)))
+
+		Expect(os.WriteFile(path, []byte("maxDiskSize: 500\n"), 0o600)).To(Succeed())
+		watcher.reload(context.Background())
+		Expect(watcher.Get().MaxDiskSize).To(Equal(int32(500)))
+
+		Expect(os.WriteFile(path, []byte("maxDiskSize: -1\n"), 0o600)).To(Succeed())
+		watcher.reload(context.Background())
+		Expect(watcher.Get().MaxDiskSize).To(Equal(int32(500)))
+	})
+
+	It("should return an empty configuration without file", func() {
+		var watcher *Watcher
+		Expect(watcher.Get()).To(Equal(&Config{}))
+	})
+})
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// How often the file is checked for changes. The kubelet takes up to a
+// minute to update mounted ConfigMaps anyway.
+const watchInterval = 10 * time.Second
+
+// Watcher holds the configuration of a file, reloading it when the file
+// changes. Invalid changes are logged and the previous configuration is kept.
+type Watcher struct {
+	path string
+
+	mu      sync.RWMutex
+	data    []byte
+	current *Config
+}
+
+// NewWatcher loads the configuration file, which must be valid
+func NewWatcher(path string) (*Watcher, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Watcher{path: path, data: data, current: config}, nil
+}
+
+// Get returns the current configuration, which must not be modified. A nil
+// watcher returns an empty configuration.
+func (w *Watcher) Get() *Config {
+	if w == nil {
+		return &Config{}
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Start reloads the file until the context is done
+func (w *Watcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			w.reload(ctx)
+		}
+	}
+}
+
+// NeedLeaderElection runs the watcher on every replica of the operator
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
+
+// reload replaces the configuration when the content of the file changed
+func (w *Watcher) reload(ctx context.Context) {
+	logger := log.FromContext(ctx).WithValues("path", w.path)
+
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		logger.Error(err, "unable to read configuration, keeping the previous one")
+		return
+	}
+	w.mu.RLock()
+	unchanged := bytes.Equal(data, w.data)
+	w.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	config, err := Parse(data)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// Do not retry the same invalid content
+	w.data = data
+	if err != nil {
+		logger.Error(err, "invalid configuration, keeping the previous one")
+		return
+	}
+	w.current = config
+	logger.Info("reloaded configuration")
+}
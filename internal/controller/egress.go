@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"path"
+	"strings"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Volume and directory of the CA bundle in the containers
+	caBundleVolumeName = "ca-bundle"
+	caBundleMountPath  = "/etc/ai-operator/certs"
+	caBundleFileName   = "ca.crt"
+)
+
+// jobEgress merges the egress settings of the AI Job over the ones of the
+// operator. The mirror of a Hugging Face model source wins over both.
+func (r *JobReconciler) jobEgress(aiJob aiv1.Job) aiv1.JobEgress {
+	egress := *r.Egress.DeepCopy()
+	if spec := aiJob.Spec.Egress; spec != nil {
+		if spec.HuggingFaceEndpoint != "" {
+			egress.HuggingFaceEndpoint = spec.HuggingFaceEndpoint
+		}
+		if spec.HuggingFaceOffline != nil {
+			egress.HuggingFaceOffline = spec.HuggingFaceOffline
+		}
+		if spec.HTTPProxy != "" {
+			egress.HTTPProxy = spec.HTTPProxy
+		}
+		if spec.HTTPSProxy != "" {
+			egress.HTTPSProxy = spec.HTTPSProxy
+		}
+		if spec.NoProxy != "" {
+			egress.NoProxy = spec.NoProxy
+		}
+		if spec.CABundle != nil {
+			egress.CABundle = spec.CABundle.DeepCopy()
+		}
+	}
+	if source := aiJob.Spec.ModelSource; source != nil && source.HuggingFace != nil && source.HuggingFace.Endpoint != "" {
+		egress.HuggingFaceEndpoint = source.HuggingFace.Endpoint
+	}
+	return egress
+}
+
+// configureEgress sets the egress environment of all the containers of the
+// pod, and mounts the CA bundle in place of the certificates of their images
+func configureEgress(egress aiv1.JobEgress, pod *corev1.PodSpec) {
+	var env []corev1.EnvVar
+	if egress.HuggingFaceEndpoint != "" {
+		env = append(env, corev1.EnvVar{Name: "HF_ENDPOINT", Value: egress.HuggingFaceEndpoint})
+	}
+	if egress.HuggingFaceOffline != nil && *egress.HuggingFaceOffline {
+		env = append(env, corev1.EnvVar{Name: "HF_HUB_OFFLINE", Value: "1"})
+	}
+	// Tools disagree on the case of the proxy variables
+	proxies := []struct{ name, value string }{
+		{"HTTP_PROXY", egress.HTTPProxy},
+		{"HTTPS_PROXY", egress.HTTPSProxy},
+		{"NO_PROXY", egress.NoProxy},
+	}
+	for _, proxy := range proxies {
+		if proxy.value != "" {
+			env = append(env,
+				corev1.EnvVar{Name: proxy.name, Value: proxy.value},
+				corev1.EnvVar{Name: strings.ToLower(proxy.name), Value: proxy.value},
+			)
+		}
+	}
+	var mounts []corev1.VolumeMount
+	if egress.CABundle != nil {
+		bundle := path.Join(caBundleMountPath, caBundleFileName)
+		for _, name := range []string{"SSL_CERT_FILE", "REQUESTS_CA_BUNDLE", "CURL_CA_BUNDLE", "AWS_CA_BUNDLE"} {
+			env = append(env, corev1.EnvVar{Name: name, Value: bundle})
+		}
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      caBundleVolumeName,
+			MountPath: caBundleMountPath,
+			ReadOnly:  true,
+		})
+		pod.Volumes = append(pod.Volumes, corev1.Volume{
+			Name: caBundleVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: egress.CABundle.ConfigMapName},
+					Items: []corev1.KeyToPath{
+						{Key: egress.CABundle.Key, Path: caBundleFileName},
+					},
+				},
+			},
+		})
+	}
+	if len(env) == 0 && len(mounts) == 0 {
+		return
+	}
+
+	for _, containers := range [][]corev1.Container{pod.InitContainers, pod.Containers} {
+		for i := range containers {
+			containers[i].Env = append(containers[i].Env, env...)
+			containers[i].VolumeMounts = append(containers[i].VolumeMounts, mounts...)
+		}
+	}
+}
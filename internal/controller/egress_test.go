@@ -0,0 +1,84 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Egress", func() {
+	offline := true
+
+	reconciler := &JobReconciler{
+		Egress: aiv1.JobEgress{
+			HuggingFaceEndpoint: "https://hf-mirror.internal",
+			HTTPSProxy:          "http://proxy.internal:3128",
+			NoProxy:             ".svc,.cluster.local",
+			CABundle:            &aiv1.CABundle{ConfigMapName: "internal-ca", Key: "ca.crt"},
+		},
+	}
+
+	It("should override the settings of the operator per job", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{
+			Egress: &aiv1.JobEgress{
+				NoProxy:  ".svc",
+				CABundle: &aiv1.CABundle{ConfigMapName: "team-ca", Key: "bundle.pem"},
+			},
+			ModelSource: &aiv1.ModelSource{
+				Type:        aiv1.ModelSourceHuggingFace,
+				HuggingFace: &aiv1.HuggingFaceSource{Endpoint: "https://team-mirror.internal"},
+			},
+		}}
+
+		egress := reconciler.jobEgress(aiJob)
+		Expect(egress.HuggingFaceEndpoint).To(Equal("https://team-mirror.internal"))
+		Expect(egress.HTTPSProxy).To(Equal("http://proxy.internal:3128"))
+		Expect(egress.NoProxy).To(Equal(".svc"))
+		Expect(egress.CABundle.ConfigMapName).To(Equal("team-ca"))
+		Expect(reconciler.Egress.NoProxy).To(Equal(".svc,.cluster.local"))
+	})
+
+	It("should configure every container of the pod", func() {
+		pod := &corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "download"}},
+			Containers:     []corev1.Container{{Name: "train"}},
+		}
+		egress := reconciler.jobEgress(aiv1.Job{})
+		egress.HuggingFaceOffline = &offline
+
+		configureEgress(egress, pod)
+		for _, container := range append(pod.InitContainers, pod.Containers...) {
+			Expect(container.Env).To(ContainElements(
+				corev1.EnvVar{Name: "HF_ENDPOINT", Value: "https://hf-mirror.internal"},
+				corev1.EnvVar{Name: "HF_HUB_OFFLINE", Value: "1"},
+				corev1.EnvVar{Name: "HTTPS_PROXY", Value: "http://proxy.internal:3128"},
+				corev1.EnvVar{Name: "https_proxy", Value: "http://proxy.internal:3128"},
+				corev1.EnvVar{Name: "REQUESTS_CA_BUNDLE", Value: "/etc/ai-operator/certs/ca.crt"},
+			))
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{
+				Name:      "ca-bundle",
+				MountPath: "/etc/ai-operator/certs",
+				ReadOnly:  true,
+			}))
+		}
+		Expect(pod.Volumes).To(HaveLen(1))
+		Expect(pod.Volumes[0].ConfigMap.Name).To(Equal("internal-ca"))
+	})
+
+	It("should leave the pod unchanged without settings", func() {
+		pod := &corev1.PodSpec{Containers: []corev1.Container{{Name: "train"}}}
+		configureEgress(aiv1.JobEgress{}, pod)
+		Expect(pod.Containers[0].Env).To(BeEmpty())
+		Expect(pod.Volumes).To(BeEmpty())
+	})
+
+	It("should reject offline jobs downloading from the Hub", func() {
+		spec := aiv1.JobSpec{HuggingFaceSecret: "token", Egress: &aiv1.JobEgress{HuggingFaceOffline: &offline}}
+		Expect(spec.Validate()).To(MatchError(ContainSubstring("offline")))
+
+		spec = aiv1.JobSpec{Egress: &aiv1.JobEgress{HTTPProxy: "proxy.internal:3128"}}
+		Expect(spec.Validate()).To(MatchError(ContainSubstring("HTTP proxy")))
+	})
+})
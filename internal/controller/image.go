@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/registry"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// resolveImage records the digest the job image points to when the job
+// starts, with the credentials of its image pull secrets
+func (r *JobReconciler) resolveImage(ctx context.Context, aiJob *aiv1.Job) error {
+	if r.Registry == nil || aiJob.Status.ImageDigest != "" {
+		return nil
+	}
+
+	keychain := registry.Keychain{}
+	for _, ref := range aiJob.Spec.ImagePullSecrets {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: aiJob.Namespace}, secret); err != nil {
+			return fmt.Errorf("failed to get image pull secret %s: %w", ref.Name, err)
+		}
+		if data, ok := secret.Data[corev1.DockerConfigJsonKey]; ok {
+			if err := keychain.AddDockerConfig(data); err != nil {
+				return fmt.Errorf("image pull secret %s: %w", ref.Name, err)
+			}
+		}
+	}
+
+	digest, err := r.Registry.Digest(ctx, aiJob.Spec.Image, keychain)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the digest of image %s: %w", aiJob.Spec.Image, err)
+	}
+	aiJob.Status.ImageDigest = digest
+	return nil
+}
+
+// configureImages sets the pull secrets and policy of the pod, and pins the
+// containers running the job image to its resolved digest
+func configureImages(aiJob aiv1.Job, pod *corev1.PodSpec) {
+	pod.ImagePullSecrets = slices.Clone(aiJob.Spec.ImagePullSecrets)
+
+	pinned := aiJob.Spec.Image
+	if aiJob.Status.ImageDigest != "" {
+		if ref, err := registry.ParseReference(aiJob.Spec.Image); err == nil {
+			pinned = ref.Pinned(aiJob.Status.ImageDigest)
+		}
+	}
+	for _, containers := range [][]corev1.Container{pod.InitContainers, pod.Containers} {
+		for i := range containers {
+			if containers[i].Image == aiJob.Spec.Image {
+				containers[i].Image = pinned
+			}
+			containers[i].ImagePullPolicy = aiJob.Spec.ImagePullPolicy
+		}
+	}
+}
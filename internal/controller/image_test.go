@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/registry"
+)
+
+var _ = Describe("Images", func() {
+	const digest = "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	newPod := func() *corev1.PodSpec {
+		return &corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "download", Image: "registry.example.com/trainer:v1"}},
+			Containers:     []corev1.Container{{Name: "job", Image: "busybox:1.37"}},
+		}
+	}
+
+	It("should run the image as given before its digest is resolved", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{Image: "registry.example.com/trainer:v1"}}
+		pod := newPod()
+		configureImages(aiJob, pod)
+		Expect(pod.InitContainers[0].Image).To(Equal("registry.example.com/trainer:v1"))
+		Expect(pod.ImagePullSecrets).To(BeEmpty())
+	})
+
+	It("should pin the job image and set the pull settings", func() {
+		aiJob := aiv1.Job{
+			Spec: aiv1.JobSpec{
+				Image:            "registry.example.com/trainer:v1",
+				ImagePullPolicy:  corev1.PullIfNotPresent,
+				ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}},
+			},
+			Status: aiv1.JobStatus{ImageDigest: digest},
+		}
+		pod := newPod()
+		configureImages(aiJob, pod)
+
+		Expect(pod.ImagePullSecrets).To(Equal(aiJob.Spec.ImagePullSecrets))
+		Expect(pod.InitContainers[0].Image).To(Equal("registry.example.com/trainer@" + digest))
+		Expect(pod.Containers[0].Image).To(Equal("busybox:1.37"))
+		for _, container := range []corev1.Container{pod.InitContainers[0], pod.Containers[0]} {
+			Expect(container.ImagePullPolicy).To(Equal(corev1.PullIfNotPresent))
+		}
+	})
+
+	It("should wait with the error in the details while the registry cannot be read", func() {
+		ctx := context.Background()
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(aiv1.AddToScheme(scheme)).To(Succeed())
+
+		aiJob := &aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "finetune", Namespace: "default"},
+			Spec:       aiv1.JobSpec{Image: "127.0.0.1:1/trainer:v1", HuggingFaceSecret: "hf-token"},
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "hf-token", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("hf_abc")},
+		}
+		r := &JobReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(aiJob, secret).
+				WithStatusSubresource(&aiv1.Job{}).
+				Build(),
+			Scheme:   scheme,
+			Registry: &registry.Client{},
+		}
+
+		request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(aiJob)}
+		for range 2 {
+			_, err := r.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(r.Get(ctx, request.NamespacedName, aiJob)).To(Succeed())
+		Expect(aiJob.Status.ImageDigest).To(BeEmpty())
+		Expect(aiJob.Status.Details).To(HavePrefix("Waiting for the image digest: "))
+	})
+})
@@ -3,18 +3,34 @@ package controller
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 	"time"
 
 	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/backend"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-func (r *JobReconciler) createJob(ctx context.Context, aiJob aiv1.Job) error {
+const (
+	// Extended resource used to request GPUs
+	jobGPUResourceName = corev1.ResourceName("nvidia.com/gpu")
+
+	// Annotation set on batch jobs suspended by the operator. The value tells
+	// whether the training had started and must resume from its checkpoint.
+	jobResumeFromCheckpointAnnotation = "ai.re-cinq.com/resume-from-checkpoint"
+)
+
+// createJob (re)creates the batch job of the AI Job. When resume is set the
+// training continues from the last checkpoint stored on the volume.
+func (r *JobReconciler) createJob(ctx context.Context, aiJob aiv1.Job, resume bool) error {
 	logger := log.FromContext(ctx)
 
 	existingJob := &batchv1.Job{}
@@ -29,24 +45,35 @@ func (r *JobReconciler) createJob(ctx context.Context, aiJob aiv1.Job) error {
 	}
 
 	// We are mounting the same volume in both init and main containers
-	volumeMounts := []corev1.VolumeMount{
-		{
-			Name:      jobDefaultVolumeName,
-			MountPath: "/tmp",
-		},
+	volumeMounts := []corev1.VolumeMount{workspaceVolumeMount(aiJob)}
+
+	// Request the GPUs for the training container
+	resources := corev1.ResourceRequirements{}
+	if aiJob.Spec.GPUs > 0 {
+		resources.Limits = corev1.ResourceList{
+			jobGPUResourceName: *resource.NewQuantity(int64(aiJob.Spec.GPUs), resource.DecimalSI),
+		}
 	}
 
-	// Set the environment variable for the Hugging Face token
-	huggingFaceTokenVar := corev1.EnvVar{
-		Name: "HF_TOKEN",
-		ValueFrom: &corev1.EnvVarSource{
-			SecretKeyRef: &corev1.SecretKeySelector{
-				LocalObjectReference: corev1.LocalObjectReference{
-					Name: huggingFaceSecretName,
-				},
-				Key: "token",
-			},
-		},
+	// The framework builds the commands, continuing the training where the
+	// previous run stopped when resuming
+	trainingBackend, err := backend.Get(aiJob.Spec.Framework)
+	if err != nil {
+		return err
+	}
+	modelDir := jobModelDir(aiJob)
+	outputDir := workspacePath(aiJob, jobWorkspace(aiJob).Output)
+	command := trainingBackend.TrainingCommand(aiJob, backend.TrainingOptions{
+		ModelDir:       modelDir,
+		OutputDir:      outputDir,
+		TensorBoardDir: jobTensorBoardDir(aiJob),
+		Resume:         resume,
+	})
+
+	// The model source downloads the model before the training starts
+	initContainer, sourceVolumes, err := downloadContainer(aiJob, trainingBackend, modelDir, volumeMounts)
+	if err != nil {
+		return err
 	}
 
 	parallelism := int32(1)
@@ -60,7 +87,10 @@ func (r *JobReconciler) createJob(ctx context.Context, aiJob aiv1.Job) error {
 			},
 		},
 		Spec: batchv1.JobSpec{
-			Parallelism: &parallelism,
+			Parallelism:           &parallelism,
+			ActiveDeadlineSeconds: aiJob.Spec.ActiveDeadlineSeconds,
+			BackoffLimit:          aiJob.Spec.BackoffLimit,
+			PodFailurePolicy:      podFailurePolicy(aiJob),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
@@ -70,39 +100,33 @@ func (r *JobReconciler) createJob(ctx context.Context, aiJob aiv1.Job) error {
 				Spec: corev1.PodSpec{
 					RuntimeClassName: &aiJob.Spec.RuntimeClassName,
 					RestartPolicy:    corev1.RestartPolicyNever,
-					InitContainers: []corev1.Container{
-						{
-							Name:  fmt.Sprintf("%s-init", aiJob.Name),
-							Image: aiJob.Spec.Image,
-							TTY:   true,
-							Command: []string{
-								"tune",
-								"download",
-								aiJob.Spec.Model,
-							},
-							Env: []corev1.EnvVar{
-								huggingFaceTokenVar,
-								{
-									Name:  "PYTHONUNBUFFERED",
-									Value: "1",
-								},
-							},
-							VolumeMounts: volumeMounts,
-						},
-					},
+					NodeSelector:     maps.Clone(aiJob.Spec.NodeSelector),
+					Tolerations:      slices.Clone(aiJob.Spec.Tolerations),
+					InitContainers:   []corev1.Container{initContainer},
 					Containers: []corev1.Container{
 						{
 							Name:    aiJob.Name,
 							Image:   aiJob.Spec.Image,
-							TTY:     true,
-							Command: aiJob.Spec.Command,
-							Env: []corev1.EnvVar{
-								huggingFaceTokenVar,
-							},
+							Command: command,
+							Env: append(huggingFaceEnv(),
+								corev1.EnvVar{
+									Name:  "PYTHONUNBUFFERED",
+									Value: "1",
+								},
+								corev1.EnvVar{
+									Name:  "MODEL_DIR",
+									Value: modelDir,
+								},
+								corev1.EnvVar{
+									Name:  "OUTPUT_DIR",
+									Value: outputDir,
+								},
+							),
+							Resources:    resources,
 							VolumeMounts: volumeMounts,
 						},
 					},
-					Volumes: []corev1.Volume{
+					Volumes: append([]corev1.Volume{
 						{
 							Name: jobDefaultVolumeName,
 							VolumeSource: corev1.VolumeSource{
@@ -111,12 +135,60 @@ func (r *JobReconciler) createJob(ctx context.Context, aiJob aiv1.Job) error {
 								},
 							},
 						},
-					},
+					}, sourceVolumes...),
 				},
 			},
 		},
 	}
 
+	// Pass the settings of the user, before those of the operator
+	configureEnv(aiJob, &job.Spec.Template.Spec)
+	configureImages(aiJob, &job.Spec.Template.Spec)
+
+	// Log the training metrics to the experiment tracker
+	configureTracking(aiJob, &job.Spec.Template.Spec.Containers[0])
+
+	// Reach the mirror, proxies and internal services of the cluster
+	configureEgress(r.jobEgress(aiJob), &job.Spec.Template.Spec)
+
+	// Tell every container where the job keeps its data
+	configureWorkspace(aiJob, &job.Spec.Template.Spec)
+
+	// Meet the restricted Pod Security Standard unless the job overrides it
+	configureSecurity(aiJob, &job.Spec.Template.Spec)
+	configureServiceAccount(aiJob, &job.Spec.Template)
+	configureNetwork(aiJob, &job.Spec.Template, jobPhaseDownload)
+
+	// Attach the fabric of the collective communications
+	configureNetworking(aiJob, &job.Spec.Template)
+
+	// Give the training container room for data loaders and mount the
+	// volumes of the user
+	configureVolumes(aiJob, &job.Spec.Template.Spec, job.Spec.Template.Spec.Containers)
+
+	// Run on the nodes selected by the operator configuration
+	r.Config.Get().Schedule(&job.Spec.Template.Spec)
+
+	// Let Kueue admit the job when the queue delegates to it
+	queue, err := r.getQueue(ctx, aiJob)
+	if err != nil {
+		return err
+	}
+	if queue != nil && queue.Spec.KueueQueueName != "" {
+		suspend := true
+		job.Spec.Suspend = &suspend
+		job.Labels[kueueQueueNameLabel] = queue.Spec.KueueQueueName
+	}
+
+	// Create the job suspended, remembering whether it has a checkpoint to resume from
+	if aiJob.Spec.Suspend {
+		suspend := true
+		job.Spec.Suspend = &suspend
+		job.Annotations = map[string]string{
+			jobResumeFromCheckpointAnnotation: strconv.FormatBool(resume),
+		}
+	}
+
 	// Set the owner reference to the AI Job
 	if err := r.setOwnerReference(&aiJob, job); err != nil {
 		return fmt.Errorf("failed to set owner reference: %w", err)
@@ -129,6 +201,141 @@ func (r *JobReconciler) createJob(ctx context.Context, aiJob aiv1.Job) error {
 	return nil
 }
 
+// configureEnv sets the arguments of the training container and the
+// environment of the job in the download and training containers. Variables
+// of the operator come after, so they take precedence over envFrom keys.
+func configureEnv(aiJob aiv1.Job, pod *corev1.PodSpec) {
+	// The download container runs the fixed command of the model source,
+	// which would reject the arguments of the training command
+	pod.Containers[0].Args = slices.Clone(aiJob.Spec.Args)
+	for _, containers := range [][]corev1.Container{pod.InitContainers, pod.Containers} {
+		for i := range containers {
+			containers[i].Env = append(slices.Clone(aiJob.Spec.Env), containers[i].Env...)
+			containers[i].EnvFrom = append(slices.Clone(aiJob.Spec.EnvFrom), containers[i].EnvFrom...)
+		}
+	}
+}
+
+// jobModelDir returns where the model is downloaded on the job volume
+func jobModelDir(aiJob aiv1.Job) string {
+	if source := aiJob.Spec.ModelSource; source != nil && source.Type == aiv1.ModelSourceVolumeSnapshot {
+		return workspacePath(aiJob, source.VolumeSnapshot.Path)
+	}
+	return workspacePath(aiJob, jobWorkspace(aiJob).Model)
+}
+
+// podFailurePolicy maps the exit codes of the training container onto the
+// batch job failure policy. Disruptions like preemption are never counted.
+func podFailurePolicy(aiJob aiv1.Job) *batchv1.PodFailurePolicy {
+	policy := aiJob.Spec.FailurePolicy
+	if policy == nil {
+		return nil
+	}
+
+	containerName := aiJob.Name
+	rules := []batchv1.PodFailurePolicyRule{
+		{
+			Action: batchv1.PodFailurePolicyActionIgnore,
+			OnPodConditions: []batchv1.PodFailurePolicyOnPodConditionsPattern{
+				{
+					Type:   corev1.DisruptionTarget,
+					Status: corev1.ConditionTrue,
+				},
+			},
+		},
+	}
+	if len(policy.FatalExitCodes) > 0 {
+		rules = append(rules, batchv1.PodFailurePolicyRule{
+			Action: batchv1.PodFailurePolicyActionFailJob,
+			OnExitCodes: &batchv1.PodFailurePolicyOnExitCodesRequirement{
+				ContainerName: &containerName,
+				Operator:      batchv1.PodFailurePolicyOnExitCodesOpIn,
+				Values:        exitCodes(policy.FatalExitCodes),
+			},
+		})
+	}
+	if len(policy.RetryableExitCodes) > 0 {
+		rules = append(rules, batchv1.PodFailurePolicyRule{
+			Action: batchv1.PodFailurePolicyActionCount,
+			OnExitCodes: &batchv1.PodFailurePolicyOnExitCodesRequirement{
+				ContainerName: &containerName,
+				Operator:      batchv1.PodFailurePolicyOnExitCodesOpIn,
+				Values:        exitCodes(policy.RetryableExitCodes),
+			},
+		})
+	}
+
+	return &batchv1.PodFailurePolicy{Rules: rules}
+}
+
+// exitCodes sorts and deduplicates the exit codes, as the batch job requires
+func exitCodes(codes []int32) []int32 {
+	codes = slices.Clone(codes)
+	slices.Sort(codes)
+	return slices.Compact(codes)
+}
+
+// suspendJob stops the pods of a running batch job when the AI Job is suspended,
+// and recreates the batch job from its last checkpoint when it is resumed.
+// A missing batch job is created again.
+func (r *JobReconciler) suspendJob(ctx context.Context, aiJob aiv1.Job) error {
+	logger := log.FromContext(ctx)
+
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, client.ObjectKey{Name: aiJob.Name, Namespace: aiJob.Namespace}, job); err != nil {
+		if apierrors.IsNotFound(err) {
+			// Only unfinished AI Jobs get here, whose batch job must exist
+			logger.Info("creating missing job")
+			return r.createJob(ctx, aiJob, false)
+		}
+		return err
+	}
+
+	resumeFromCheckpoint, suspended := job.Annotations[jobResumeFromCheckpointAnnotation]
+	switch {
+	case aiJob.Spec.Suspend && !suspended:
+		if jobFinished(job) {
+			return nil
+		}
+		resume := checkpointSaved(aiJob)
+		logger.Info("suspending job", "fromCheckpoint", resume)
+		patch := client.MergeFrom(job.DeepCopy())
+		suspend := true
+		job.Spec.Suspend = &suspend
+		if job.Annotations == nil {
+			job.Annotations = map[string]string{}
+		}
+		job.Annotations[jobResumeFromCheckpointAnnotation] = strconv.FormatBool(resume)
+		return r.Patch(ctx, job, patch)
+	case !aiJob.Spec.Suspend && suspended:
+		// The pod template of a started job cannot change, so recreate it
+		logger.Info("resuming job", "fromCheckpoint", resumeFromCheckpoint)
+		return r.createJob(ctx, aiJob, resumeFromCheckpoint == "true")
+	}
+
+	return nil
+}
+
+// checkpointSaved reports whether the training of the AI Job left a checkpoint
+// on the volume to resume from. The progress is only read from the logs of a
+// started training container, and the recipes write their first checkpoint
+// at the end of the first epoch.
+func checkpointSaved(aiJob aiv1.Job) bool {
+	progress := aiJob.Status.Progress
+	return progress != nil && progress.Epoch > 1
+}
+
+// jobFinished reports whether the batch job completed or failed
+func jobFinished(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) &&
+			condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *JobReconciler) deleteJob(ctx context.Context, aiJob aiv1.Job) error {
 	logger := log.FromContext(ctx)
 	job := &batchv1.Job{
@@ -167,3 +374,50 @@ func (r *JobReconciler) waitForJobDeletion(ctx context.Context, aiJob aiv1.Job)
 		time.Sleep(time.Second)
 	}
 }
+
+// syncJobStatus derives the AI Job state from the conditions of its batch job
+func (r *JobReconciler) syncJobStatus(ctx context.Context, aiJob *aiv1.Job) error {
+	// The job has been admitted at this point
+	aiJob.Status.QueuePosition = 0
+	aiJob.Status.OutputType = outputType(aiJob.Spec.Method)
+	if waitingForAdmission(*aiJob) {
+		aiJob.Status.State = aiv1.JobStateRunning
+		aiJob.Status.Details = ""
+	}
+
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, client.ObjectKey{Name: aiJob.Name, Namespace: aiJob.Namespace}, job); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			if len(aiJob.Spec.Postprocess) > 0 {
+				return r.syncPostprocess(ctx, aiJob)
+			}
+			aiJob.Status.State = aiv1.JobStateSucceeded
+			aiJob.Status.Details = condition.Message
+			aiJob.Status.CompletionTime = &condition.LastTransitionTime
+			return nil
+		case batchv1.JobFailed:
+			aiJob.Status.State = aiv1.JobStateFailed
+			aiJob.Status.Details = condition.Message
+			aiJob.Status.CompletionTime = &condition.LastTransitionTime
+			return nil
+		}
+	}
+
+	if aiJob.Spec.Suspend {
+		aiJob.Status.State = aiv1.JobStateSuspended
+		aiJob.Status.Details = "Pods are stopped, the volume and checkpoints are kept"
+		return nil
+	}
+
+	aiJob.Status.State = aiv1.JobStateRunning
+	aiJob.Status.Details = ""
+	return nil
+}
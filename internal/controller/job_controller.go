@@ -18,32 +18,67 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"slices"
 	"time"
 
 	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/config"
+	"github.com/re-cinq/ai-operator/internal/policy"
+	"github.com/re-cinq/ai-operator/internal/registry"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
 const (
 	jobFinalizerName     = "job.ai.re-cinq.com/finalizer"
 	jobDefaultVolumeName = "model"
+
+	// How often queued jobs check for free capacity
+	jobQueueRequeueInterval = time.Second * 30
 )
 
 // JobReconciler reconciles a Job object
 type JobReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// Clientset reads the training logs to track progress, which is disabled when nil
+	Clientset kubernetes.Interface
+
+	// Egress of the job containers, overridden by the egress of each AI Job
+	Egress aiv1.JobEgress
+
+	// Cluster-wide defaults and limits of the jobs, none when nil
+	Config *config.Watcher
+
+	// Registry resolves the job images to digests, which are not pinned when nil
+	Registry *registry.Client
 }
 
 // +kubebuilder:rbac:groups=core,resources=secrets;persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;patch
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods/log,verbs=get
+// +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;delete
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=ai.re-cinq.com,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=ai.re-cinq.com,resources=jobs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=ai.re-cinq.com,resources=jobs/finalizers,verbs=update
+// +kubebuilder:rbac:groups=ai.re-cinq.com,resources=queues,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ai.re-cinq.com,resources=jobtemplates;clusterjobtemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ai.re-cinq.com,resources=aijobpolicies;clusteraijobpolicies,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -67,8 +102,20 @@ func (r *JobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		// on deleted requests.
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	originalStatus := aiJob.Status.DeepCopy()
+
+	// Start from the template of the job, which must exist until the job starts
+	if err := r.applyTemplate(ctx, &aiJob); err != nil && aiJob.DeletionTimestamp.IsZero() {
+		logger.Error(err, "failed to apply job template")
+		if err := r.reject(ctx, &aiJob, originalStatus, err); err != nil {
+			return ctrl.Result{RequeueAfter: time.Second * 5}, err
+		}
+		return ctrl.Result{RequeueAfter: jobQueueRequeueInterval}, nil
+	}
 
-	// Make sure we have a valid spec
+	// Make sure we have a valid spec, defaulted by the operator configuration first
+	operatorConfig := r.Config.Get()
+	operatorConfig.ApplyDefaults(&aiJob.Spec)
 	if err := aiJob.Spec.Validate(); err != nil {
 		logger.Error(err, "invalid job spec")
 		return ctrl.Result{}, client.IgnoreNotFound(err)
@@ -83,52 +130,251 @@ func (r *JobReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		return ctrl.Result{}, nil
 	}
 
-	// Add finalizer if it doesn't exist
+	// Add finalizer if it doesn't exist, patching only the finalizers so that
+	// the spec merged over the template and defaulted stays in memory
 	if !slices.Contains(aiJob.Finalizers, jobFinalizerName) {
+		patch := client.MergeFromWithOptions(aiJob.DeepCopy(), client.MergeFromWithOptimisticLock{})
 		aiJob.Finalizers = append(aiJob.Finalizers, jobFinalizerName)
-		if err := r.Update(ctx, &aiJob); err != nil {
+		if err := r.Patch(ctx, &aiJob, patch); err != nil {
 			logger.Error(err, "failed to add finalizer")
 			return ctrl.Result{RequeueAfter: time.Second * 5}, err
 		}
 		return ctrl.Result{}, nil
 	}
 
+	// Finished jobs keep their final status, only their resources expire
+	if aiJob.Status.State == aiv1.JobStateSucceeded || aiJob.Status.State == aiv1.JobStateFailed {
+		requeueAfter, err := r.expire(ctx, aiJob)
+		if err != nil {
+			logger.Error(err, "failed to delete expired resources")
+			return ctrl.Result{RequeueAfter: time.Second * 15}, err
+		}
+		viewerRequeueAfter, err := r.syncTensorBoard(ctx, &aiJob)
+		if err != nil {
+			logger.Error(err, "failed to reconcile TensorBoard viewer")
+			return ctrl.Result{RequeueAfter: time.Second * 15}, err
+		}
+		notifyRequeueAfter := r.notify(ctx, &aiJob)
+		if err := r.updateStatus(ctx, &aiJob, originalStatus); err != nil {
+			logger.Error(err, "failed to update status")
+			return ctrl.Result{RequeueAfter: time.Second * 5}, err
+		}
+		return ctrl.Result{RequeueAfter: shortestRequeue(requeueAfter, viewerRequeueAfter, notifyRequeueAfter)}, nil
+	}
+
+	// Jobs that did not start yet must respect the limits of the operator,
+	// which are checked again once the configuration changes
+	switch aiJob.Status.State {
+	case "", aiv1.JobStateQueued, aiv1.JobStateRejected:
+		if err := operatorConfig.Check(aiJob.Spec); err != nil {
+			logger.Error(err, "job spec rejected by the operator configuration")
+			if err := r.reject(ctx, &aiJob, originalStatus, err); err != nil {
+				return ctrl.Result{RequeueAfter: time.Second * 5}, err
+			}
+			return ctrl.Result{RequeueAfter: jobQueueRequeueInterval}, nil
+		}
+
+		// The admission webhook checks the spec of the job when it is created,
+		// the template may only be created later. The merged spec is checked
+		// against the policies until it is recorded.
+		if aiJob.Spec.TemplateRef != nil && aiJob.Status.EffectiveSpec == nil {
+			if _, err := policy.Evaluate(ctx, r.Client, aiJob); err != nil {
+				logger.Error(err, "job spec merged over its template rejected by the policies")
+				if err := r.reject(ctx, &aiJob, originalStatus, err); err != nil {
+					return ctrl.Result{RequeueAfter: time.Second * 5}, err
+				}
+				return ctrl.Result{RequeueAfter: jobQueueRequeueInterval}, nil
+			}
+		}
+	}
+
+	// Hold the job back until its queue has capacity
+	position, err := r.admit(ctx, aiJob)
+	if isJobRejected(err) {
+		// Retrying does not help, wait for the job or its queue to change,
+		// which the Queue watch reconciles
+		logger.Info("AI Job rejected by its queue", "reason", err.Error())
+		if err := r.reject(ctx, &aiJob, originalStatus, err); err != nil {
+			logger.Error(err, "failed to update status")
+			return ctrl.Result{RequeueAfter: time.Second * 5}, err
+		}
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		logger.Error(err, "failed to admit job")
+		return ctrl.Result{RequeueAfter: time.Second * 15}, err
+	}
+	if position > 0 {
+		aiJob.Status.State = aiv1.JobStateQueued
+		aiJob.Status.QueuePosition = position
+		aiJob.Status.Details = fmt.Sprintf("Waiting for capacity in queue %s", queueName(aiJob))
+		notifyRequeueAfter := r.notify(ctx, &aiJob)
+		if err := r.updateStatus(ctx, &aiJob, originalStatus); err != nil {
+			logger.Error(err, "failed to update status")
+			return ctrl.Result{RequeueAfter: time.Second * 5}, err
+		}
+		return ctrl.Result{RequeueAfter: shortestRequeue(jobQueueRequeueInterval, notifyRequeueAfter)}, nil
+	}
+
+	// Record the spec the job runs with once it was merged over its template
+	if aiJob.Spec.TemplateRef != nil && aiJob.Status.EffectiveSpec == nil {
+		aiJob.Status.EffectiveSpec = aiJob.Spec.DeepCopy()
+	}
+
+	// Publish where the job keeps its artifacts, which stays fixed for the
+	// life of the volume
+	if aiJob.Status.Workspace == nil {
+		workspace := jobWorkspace(aiJob)
+		aiJob.Status.Workspace = &workspace
+	}
+
+	// Pin the image to the digest its tag points to when the job starts
+	if err := r.resolveImage(ctx, &aiJob); err != nil {
+		logger.Error(err, "failed to resolve image digest")
+		aiJob.Status.Details = fmt.Sprintf("Waiting for the image digest: %s", err)
+		if err := r.updateStatus(ctx, &aiJob, originalStatus); err != nil {
+			return ctrl.Result{RequeueAfter: time.Second * 5}, err
+		}
+		return ctrl.Result{RequeueAfter: time.Second * 15}, nil
+	}
+
+	// Create the tracking run before the training starts, and keep it on failures
+	if err := r.startTracking(ctx, &aiJob); err != nil {
+		logger.Error(err, "failed to start experiment tracking")
+		return ctrl.Result{RequeueAfter: time.Second * 15}, err
+	}
+	if err := r.updateStatus(ctx, &aiJob, originalStatus); err != nil {
+		logger.Error(err, "failed to update status")
+		return ctrl.Result{RequeueAfter: time.Second * 5}, err
+	}
+	originalStatus = aiJob.Status.DeepCopy()
+
 	// Handle creation/update
 	if err := r.create(ctx, aiJob); err != nil {
+		// Objects of others are never adopted, wait for them to go away
+		var conflict *ownershipConflictError
+		if errors.As(err, &conflict) {
+			logger.Info("resource of the job owned by others", "reason", err.Error())
+			aiJob.Status.Details = fmt.Sprintf("Conflict: %s", err)
+			if err := r.updateStatus(ctx, &aiJob, originalStatus); err != nil {
+				return ctrl.Result{RequeueAfter: time.Second * 5}, err
+			}
+			return ctrl.Result{RequeueAfter: jobQueueRequeueInterval}, nil
+		}
 		logger.Error(err, "failed to reconcile resources")
 		return ctrl.Result{RequeueAfter: time.Second * 15}, err
 	}
 
-	return ctrl.Result{}, nil
+	// Reflect the batch job state in the AI Job status
+	if err := r.syncJobStatus(ctx, &aiJob); err != nil {
+		logger.Error(err, "failed to get job status")
+		return ctrl.Result{RequeueAfter: time.Second * 15}, err
+	}
+	// Restrict the pods that downloaded the model to the training egress
+	if err := r.syncNetworkPhase(ctx, aiJob); err != nil {
+		logger.Error(err, "failed to sync network phase")
+	}
+	// Track the training progress, failures only delay the next read
+	if err := r.syncProgress(ctx, &aiJob); err != nil {
+		logger.Error(err, "failed to read training progress")
+	}
+	if _, err := r.syncTensorBoard(ctx, &aiJob); err != nil {
+		logger.Error(err, "failed to reconcile TensorBoard viewer")
+		return ctrl.Result{RequeueAfter: time.Second * 15}, err
+	}
+	// Notify the state changes, failed deliveries are retried with a backoff
+	requeueAfter := r.notify(ctx, &aiJob)
+	if err := r.updateStatus(ctx, &aiJob, originalStatus); err != nil {
+		logger.Error(err, "failed to update status")
+		return ctrl.Result{RequeueAfter: time.Second * 5}, err
+	}
+
+	// Poll the progress of running jobs
+	if aiJob.Status.State == aiv1.JobStateRunning && r.Clientset != nil {
+		requeueAfter = shortestRequeue(requeueAfter, jobProgressInterval)
+	}
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *JobReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&aiv1.Job{}).
+		Owns(&batchv1.Job{}).
+		// Admit the waiting jobs again when the limits of their queue change
+		Watches(&aiv1.Queue{}, handler.EnqueueRequestsFromMapFunc(r.queuedJobs)).
 		Named("job").
 		Complete(r)
 }
 
+// queuedJobs returns the AI Jobs of the namespace of the Queue that wait for
+// admission. Jobs are not matched by queue name, as the name of a templated
+// job may come from its template.
+func (r *JobReconciler) queuedJobs(ctx context.Context, queue client.Object) []reconcile.Request {
+	var aiJobs aiv1.JobList
+	if err := r.List(ctx, &aiJobs, client.InNamespace(queue.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "failed to list the jobs of queue", "queue", queue.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, aiJob := range aiJobs.Items {
+		if waitingForAdmission(aiJob) {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&aiJob)})
+		}
+	}
+	return requests
+}
+
 func (r *JobReconciler) setOwnerReference(aiJob *aiv1.Job, obj client.Object) error {
 	return ctrl.SetControllerReference(aiJob, obj, r.Scheme)
 }
 
-//  func (r *JobReconciler) updateStatus(ctx context.Context, aiJob *aiv1.Job, state, details string) error {
-//	  aiJob.Status.State = state
-//	  aiJob.Status.Details = details
-//	  return r.Status().Update(ctx, aiJob)
-//  }
+// shortestRequeue returns the shortest of the positive delays, or 0 when there is none
+func shortestRequeue(delays ...time.Duration) time.Duration {
+	var shortest time.Duration
+	for _, delay := range delays {
+		if delay > 0 && (shortest == 0 || delay < shortest) {
+			shortest = delay
+		}
+	}
+	return shortest
+}
+
+// reject moves the AI Job to the Rejected state, which does not hold a place
+// in its queue, with the reason in its details
+func (r *JobReconciler) reject(ctx context.Context, aiJob *aiv1.Job, original *aiv1.JobStatus, reason error) error {
+	aiJob.Status.State = aiv1.JobStateRejected
+	aiJob.Status.QueuePosition = 0
+	aiJob.Status.Details = fmt.Sprintf("Rejected: %s", reason)
+	return r.updateStatus(ctx, aiJob, original)
+}
+
+// updateStatus persists the AI Job status when it differs from the original one
+func (r *JobReconciler) updateStatus(ctx context.Context, aiJob *aiv1.Job, original *aiv1.JobStatus) error {
+	if equality.Semantic.DeepEqual(original, &aiJob.Status) {
+		return nil
+	}
+	// The update returns the stored spec, while the reconciliation goes on
+	// with the one merged over the template and defaulted
+	spec := aiJob.Spec
+	err := r.Status().Update(ctx, aiJob)
+	aiJob.Spec = spec
+	return err
+}
 
 // Delete the AI Job
 func (r *JobReconciler) delete(ctx context.Context, aiJob aiv1.Job) error {
-	// Delete the Job
+	// Delete the Jobs
 	if err := r.deleteJob(ctx, aiJob); err != nil {
 		return err
 	}
+	if err := r.deletePostprocessJob(ctx, aiJob); err != nil {
+		return err
+	}
 
-	// Delete the PVC
-	if err := r.deletePVC(ctx, aiJob); err != nil {
+	// Delete or retain the PVC
+	if err := r.releasePVC(ctx, aiJob); err != nil {
 		return err
 	}
 
@@ -137,21 +383,64 @@ func (r *JobReconciler) delete(ctx context.Context, aiJob aiv1.Job) error {
 		return err
 	}
 
+	// Delete the ServiceAccount created for the job
+	if err := r.deleteServiceAccount(ctx, aiJob); err != nil {
+		return err
+	}
+
 	// Remove finalizer after successful deletion
+	patch := client.MergeFromWithOptions(aiJob.DeepCopy(), client.MergeFromWithOptimisticLock{})
 	aiJob.Finalizers = slices.DeleteFunc(aiJob.Finalizers, func(s string) bool {
 		return s == jobFinalizerName
 	})
-	if err := r.Update(ctx, &aiJob); err != nil {
+	if err := r.Patch(ctx, &aiJob, patch); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// Delete the Job and release the PVC of a finished AI Job once its TTL has
+// passed. The Secret is shared by the jobs of the namespace and is kept.
+// Returns how long to wait before the resources expire.
+func (r *JobReconciler) expire(ctx context.Context, aiJob aiv1.Job) (time.Duration, error) {
+	if aiJob.Spec.TTLSecondsAfterFinished == nil || aiJob.Status.CompletionTime == nil {
+		return 0, nil
+	}
+
+	ttl := time.Duration(*aiJob.Spec.TTLSecondsAfterFinished) * time.Second
+	if remaining := time.Until(aiJob.Status.CompletionTime.Add(ttl)); remaining > 0 {
+		return remaining, nil
+	}
+
+	if err := r.deleteJob(ctx, aiJob); err != nil {
+		return 0, err
+	}
+	if err := r.deletePostprocessJob(ctx, aiJob); err != nil {
+		return 0, err
+	}
+	return 0, r.releasePVC(ctx, aiJob)
+}
+
 // Called when an AI Job is created or updated
 func (r *JobReconciler) create(ctx context.Context, aiJob aiv1.Job) error {
-	secretUpdated, err := r.createSecret(ctx, aiJob)
-	if err != nil {
+	// Jobs without a token keep the token of the other jobs of the namespace
+	secretUpdated := false
+	if aiJob.Spec.HuggingFaceSecret != "" {
+		updated, err := r.createSecret(ctx, aiJob)
+		if err != nil {
+			return err
+		}
+		secretUpdated = updated
+	}
+
+	// The pods of the job run as its ServiceAccount
+	if err := r.createServiceAccount(ctx, aiJob); err != nil {
+		return err
+	}
+
+	// Restrict the egress before the pods start
+	if err := r.createNetworkPolicies(ctx, aiJob); err != nil {
 		return err
 	}
 
@@ -160,13 +449,14 @@ func (r *JobReconciler) create(ctx context.Context, aiJob aiv1.Job) error {
 		return err
 	}
 
-	// If secret was updated, recreate the job
 	// If secret or PVC was updated, recreate the job
 	if secretUpdated || pvcUpdated {
-		if err := r.createJob(ctx, aiJob); err != nil {
+		if err := r.createJob(ctx, aiJob, false); err != nil {
 			return err
 		}
+		return nil
 	}
 
-	return nil
+	// Suspend or resume the existing job, or create it when missing
+	return r.suspendJob(ctx, aiJob)
 }
@@ -18,11 +18,18 @@ package controller
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -83,4 +90,473 @@ var _ = Describe("Job Controller", func() {
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
 	})
+
+	Context("When the job is suspended", func() {
+		const resourceName = "suspended-job"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			resource := &aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: aiv1.JobSpec{
+					HuggingFaceSecret: "test-secret",
+					Suspend:           true,
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, &aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			})).To(Succeed())
+		})
+
+		It("should create the batch job suspended", func() {
+			controllerReconciler := &JobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("reconciling twice to add the finalizer and then create the resources")
+			for range 2 {
+				_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: typeNamespacedName,
+				})
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			job := &batchv1.Job{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, job)).To(Succeed())
+			Expect(job.Spec.Suspend).To(HaveValue(BeTrue()))
+
+			aiJob := &aiv1.Job{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, aiJob)).To(Succeed())
+			Expect(aiJob.Status.State).To(Equal(aiv1.JobStateSuspended))
+		})
+	})
+
+	Context("When a job with a Retain policy is deleted", func() {
+		const resourceName = "retained-job"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			resource := &aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: aiv1.JobSpec{
+					HuggingFaceSecret: "test-secret",
+					RetentionPolicy:   aiv1.RetentionPolicyRetain,
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		It("should keep the PVC without owner", func() {
+			controllerReconciler := &JobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			request := reconcile.Request{NamespacedName: typeNamespacedName}
+
+			By("reconciling twice to add the finalizer and then create the resources")
+			for range 2 {
+				_, err := controllerReconciler.Reconcile(ctx, request)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("deleting the AI Job")
+			Expect(k8sClient.Delete(ctx, &aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			})).To(Succeed())
+			_, err := controllerReconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			pvc := &corev1.PersistentVolumeClaim{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, pvc)).To(Succeed())
+			Expect(pvc.OwnerReferences).To(BeEmpty())
+			Expect(pvc.Labels).To(HaveKeyWithValue("ai.re-cinq.com/job", resourceName))
+			Expect(pvc.Labels).To(HaveKeyWithValue("ai.re-cinq.com/model", "Qwen.Qwen2.5-0.5B-Instruct"))
+		})
+	})
+
+	Context("When the queue is full", func() {
+		const namespace = "default"
+
+		ctx := context.Background()
+
+		newJob := func(name string, gpus int32) *aiv1.Job {
+			return &aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+				Spec: aiv1.JobSpec{
+					HuggingFaceSecret: "test-secret",
+					QueueName:         "limited",
+					GPUs:              gpus,
+				},
+			}
+		}
+
+		BeforeEach(func() {
+			By("creating a queue that admits a single job")
+			queue := &aiv1.Queue{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "limited",
+					Namespace: namespace,
+				},
+				Spec: aiv1.QueueSpec{
+					MaxConcurrentJobs: 1,
+					MaxGPUs:           2,
+				},
+			}
+			Expect(k8sClient.Create(ctx, queue)).To(Succeed())
+
+			By("creating a running job in the queue")
+			running := newJob("running-job", 2)
+			Expect(k8sClient.Create(ctx, running)).To(Succeed())
+			running.Status.State = aiv1.JobStateRunning
+			Expect(k8sClient.Status().Update(ctx, running)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(k8sClient.Delete(ctx, &aiv1.Queue{
+				ObjectMeta: metav1.ObjectMeta{Name: "limited", Namespace: namespace},
+			})).To(Succeed())
+			for _, name := range []string{"running-job", "queued-job", "large-job", "templated-job"} {
+				Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &aiv1.Job{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				}))).To(Succeed())
+			}
+		})
+
+		It("should keep the job queued with its position", func() {
+			queued := newJob("queued-job", 1)
+			Expect(k8sClient.Create(ctx, queued)).To(Succeed())
+
+			controllerReconciler := &JobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("reconciling twice to add the finalizer and then admit the job")
+			request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(queued)}
+			for range 2 {
+				_, err := controllerReconciler.Reconcile(ctx, request)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(k8sClient.Get(ctx, request.NamespacedName, queued)).To(Succeed())
+			Expect(queued.Status.State).To(Equal(aiv1.JobStateQueued))
+			Expect(queued.Status.QueuePosition).To(Equal(int32(1)))
+
+			By("checking that no batch job was created")
+			err := k8sClient.Get(ctx, request.NamespacedName, &batchv1.Job{})
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should reject a job larger than the queue without requeueing it", func() {
+			large := newJob("large-job", 4)
+			Expect(k8sClient.Create(ctx, large)).To(Succeed())
+
+			controllerReconciler := &JobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("reconciling twice to add the finalizer and then reject the job")
+			request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(large)}
+			var result reconcile.Result
+			for range 2 {
+				var err error
+				result, err = controllerReconciler.Reconcile(ctx, request)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(result.RequeueAfter).To(BeZero())
+
+			Expect(k8sClient.Get(ctx, request.NamespacedName, large)).To(Succeed())
+			Expect(large.Status.State).To(Equal(aiv1.JobStateRejected))
+			Expect(large.Status.Details).To(ContainSubstring("allows at most 2"))
+
+			By("checking that the rejected job does not hold a place in the queue")
+			queued := newJob("queued-job", 1)
+			queued.Spec.Priority = -1
+			Expect(k8sClient.Create(ctx, queued)).To(Succeed())
+			queuedRequest := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(queued)}
+			for range 2 {
+				_, err := controllerReconciler.Reconcile(ctx, queuedRequest)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(k8sClient.Get(ctx, queuedRequest.NamespacedName, queued)).To(Succeed())
+			Expect(queued.Status.QueuePosition).To(Equal(int32(1)))
+		})
+
+		It("should reject a job whose template does not exist", func() {
+			templated := newJob("templated-job", 1)
+			templated.Spec.TemplateRef = &aiv1.TemplateReference{Name: "missing"}
+			Expect(k8sClient.Create(ctx, templated)).To(Succeed())
+
+			controllerReconciler := &JobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(templated)}
+			_, err := controllerReconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, request.NamespacedName, templated)).To(Succeed())
+			Expect(templated.Status.State).To(Equal(aiv1.JobStateRejected))
+			Expect(templated.Status.Details).To(HavePrefix("Rejected: "))
+		})
+	})
+
+	Context("When tracking the job with MLflow", func() {
+		const resourceName = "tracked-job"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			By("starting an MLflow compatible tracking server")
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /api/2.0/mlflow/experiments/get-by-name", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"experiment":{"experiment_id":"7"}}`))
+			})
+			mux.HandleFunc("POST /api/2.0/mlflow/runs/create", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"run":{"info":{"run_id":"abc","experiment_id":"7"}}}`))
+			})
+			server = httptest.NewServer(mux)
+
+			resource := &aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: aiv1.JobSpec{
+					HuggingFaceSecret: "test-secret",
+					Tracking: &aiv1.JobTracking{
+						Provider: aiv1.TrackingProviderMLflow,
+						MLflow:   &aiv1.MLflowTracking{TrackingURI: server.URL},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			server.Close()
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+		})
+
+		It("should record the run and configure the metric logger", func() {
+			controllerReconciler := &JobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			request := reconcile.Request{NamespacedName: typeNamespacedName}
+
+			By("reconciling twice to add the finalizer and then create the resources")
+			for range 2 {
+				_, err := controllerReconciler.Reconcile(ctx, request)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			aiJob := &aiv1.Job{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, aiJob)).To(Succeed())
+			Expect(aiJob.Status.Tracking).NotTo(BeNil())
+			Expect(aiJob.Status.Tracking.RunID).To(Equal("abc"))
+			Expect(aiJob.Status.Tracking.RunURL).To(Equal(server.URL + "/#/experiments/7/runs/abc"))
+
+			job := &batchv1.Job{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, job)).To(Succeed())
+			container := job.Spec.Template.Spec.Containers[0]
+			Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "MLFLOW_RUN_ID", Value: "abc"}))
+			Expect(container.Command).To(ContainElement("metric_logger._component_=torchtune.training.metric_logging.MLFlowLogger"))
+		})
+	})
+
+	Context("When the TensorBoard viewer is enabled", func() {
+		const resourceName = "viewed-job"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		viewerName := types.NamespacedName{
+			Name:      resourceName + "-tensorboard",
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			resource := &aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: aiv1.JobSpec{
+					HuggingFaceSecret: "test-secret",
+					TensorBoard:       &aiv1.JobTensorBoard{Enabled: true},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+		})
+
+		It("should serve the volume until the TTL after completion", func() {
+			controllerReconciler := &JobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			request := reconcile.Request{NamespacedName: typeNamespacedName}
+
+			By("reconciling twice to add the finalizer and then create the resources")
+			for range 2 {
+				_, err := controllerReconciler.Reconcile(ctx, request)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, viewerName, deployment)).To(Succeed())
+			volume := deployment.Spec.Template.Spec.Volumes[0]
+			Expect(volume.PersistentVolumeClaim.ClaimName).To(Equal(resourceName))
+			Expect(volume.PersistentVolumeClaim.ReadOnly).To(BeTrue())
+			Expect(k8sClient.Get(ctx, viewerName, &corev1.Service{})).To(Succeed())
+
+			aiJob := &aiv1.Job{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, aiJob)).To(Succeed())
+			Expect(aiJob.Status.TensorBoardURL).To(Equal("http://viewed-job-tensorboard.default.svc:6006"))
+
+			By("finishing the job longer than the TTL ago")
+			completed := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+			aiJob.Status.State = aiv1.JobStateSucceeded
+			aiJob.Status.CompletionTime = &completed
+			Expect(k8sClient.Status().Update(ctx, aiJob)).To(Succeed())
+
+			_, err := controllerReconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, viewerName, &appsv1.Deployment{}))).To(BeTrue())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, aiJob)).To(Succeed())
+			Expect(aiJob.Status.TensorBoardURL).To(BeEmpty())
+		})
+	})
+
+	Context("When notifying the completion of a job", func() {
+		const resourceName = "notified-job"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		var (
+			server   *httptest.Server
+			received chan string
+		)
+
+		BeforeEach(func() {
+			received = make(chan string, 1)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received <- r.Header.Get("ce-type")
+			}))
+
+			resource := &aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      resourceName,
+					Namespace: "default",
+				},
+				Spec: aiv1.JobSpec{
+					HuggingFaceSecret: "test-secret",
+					Notifications: []aiv1.JobNotification{
+						{
+							Name:   "events",
+							URL:    server.URL,
+							Format: aiv1.NotificationFormatCloudEvents,
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			server.Close()
+			Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, &aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			}))).To(Succeed())
+		})
+
+		It("should deliver the notification once", func() {
+			controllerReconciler := &JobReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			request := reconcile.Request{NamespacedName: typeNamespacedName}
+
+			By("reconciling to add the finalizer")
+			_, err := controllerReconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("finishing the job")
+			aiJob := &aiv1.Job{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, aiJob)).To(Succeed())
+			completed := metav1.Now()
+			aiJob.Status.State = aiv1.JobStateSucceeded
+			aiJob.Status.CompletionTime = &completed
+			Expect(k8sClient.Status().Update(ctx, aiJob)).To(Succeed())
+
+			By("reconciling twice to notify the completion")
+			for range 2 {
+				_, err := controllerReconciler.Reconcile(ctx, request)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			Expect(received).To(Receive(Equal("com.re-cinq.ai.job.state")))
+			Expect(received).NotTo(Receive())
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, aiJob)).To(Succeed())
+			Expect(aiJob.Status.Notifications).To(ConsistOf(And(
+				HaveField("Name", "events"),
+				HaveField("State", aiv1.JobStateSucceeded),
+				HaveField("Delivered", true),
+				HaveField("Attempts", int32(1)),
+			)))
+		})
+	})
 })
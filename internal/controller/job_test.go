@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Environment", func() {
+	It("should pass the env and arguments of the job before those of the operator", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{
+			Args: []string{"--num_train_epochs", "3"},
+			Env:  []corev1.EnvVar{{Name: "DATASET", Value: "alpaca"}},
+			EnvFrom: []corev1.EnvFromSource{{
+				ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "settings"}},
+			}},
+		}}
+		operatorEnv := corev1.EnvVar{Name: "MODEL_DIR", Value: "/workspace/model"}
+		pod := &corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "download", Env: []corev1.EnvVar{operatorEnv}}},
+			Containers:     []corev1.Container{{Name: "job", Env: []corev1.EnvVar{operatorEnv}}},
+		}
+		configureEnv(aiJob, pod)
+
+		for _, container := range []corev1.Container{pod.InitContainers[0], pod.Containers[0]} {
+			Expect(container.Env).To(Equal([]corev1.EnvVar{aiJob.Spec.Env[0], operatorEnv}))
+			Expect(container.EnvFrom).To(Equal(aiJob.Spec.EnvFrom))
+		}
+		Expect(pod.InitContainers[0].Args).To(BeEmpty())
+		Expect(pod.Containers[0].Args).To(Equal(aiJob.Spec.Args))
+	})
+
+	It("should reject the variables of the operator", func() {
+		for _, name := range []string{"HF_TOKEN", "WORKSPACE_DIR", "NCCL_DEBUG"} {
+			spec := aiv1.JobSpec{HuggingFaceSecret: "hf-token", Env: []corev1.EnvVar{{Name: name, Value: "x"}}}
+			Expect(spec.Validate()).To(MatchError(ContainSubstring("set by the operator")))
+		}
+	})
+})
+
+var _ = Describe("Suspend", func() {
+	ctx := context.Background()
+
+	suspend := func(progress *aiv1.JobProgress) string {
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "finetune", Namespace: "default"}}
+		r := &JobReconciler{Client: fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(job).Build()}
+		aiJob := aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "finetune", Namespace: "default"},
+			Spec:       aiv1.JobSpec{Suspend: true},
+			Status:     aiv1.JobStatus{Progress: progress},
+		}
+		Expect(r.suspendJob(ctx, aiJob)).To(Succeed())
+
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(job), job)).To(Succeed())
+		Expect(job.Spec.Suspend).To(HaveValue(BeTrue()))
+		return job.Annotations[jobResumeFromCheckpointAnnotation]
+	}
+
+	It("should restart from scratch when the training saved no checkpoint", func() {
+		Expect(suspend(nil)).To(Equal("false"))
+		Expect(suspend(&aiv1.JobProgress{Epoch: 1, Step: 13})).To(Equal("false"))
+	})
+
+	It("should resume from the checkpoint written after the first epoch", func() {
+		Expect(suspend(&aiv1.JobProgress{Epoch: 2, Step: 30})).To(Equal("true"))
+	})
+})
+
+var _ = Describe("Failures and cleanup", func() {
+	ctx := context.Background()
+
+	newReconciler := func(objects ...client.Object) *JobReconciler {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(aiv1.AddToScheme(scheme)).To(Succeed())
+		return &JobReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			Scheme: scheme,
+		}
+	}
+
+	newJob := func() aiv1.Job {
+		deadline, backoff, ttl := int64(86400), int32(3), int32(60)
+		aiJob := aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "finetune", Namespace: "default", UID: "uid"},
+			Spec: aiv1.JobSpec{
+				HuggingFaceSecret:       "hf-token",
+				ActiveDeadlineSeconds:   &deadline,
+				BackoffLimit:            &backoff,
+				TTLSecondsAfterFinished: &ttl,
+				FailurePolicy: &aiv1.JobFailurePolicy{
+					RetryableExitCodes: []int32{137, 1, 137},
+					FatalExitCodes:     []int32{2},
+				},
+			},
+		}
+		Expect(aiJob.Spec.Validate()).To(Succeed())
+		return aiJob
+	}
+
+	It("should pass the deadline, backoff and failure policy to the batch job", func() {
+		aiJob := newJob()
+		r := newReconciler()
+		Expect(r.createJob(ctx, aiJob, false)).To(Succeed())
+
+		job := &batchv1.Job{}
+		Expect(r.Get(ctx, client.ObjectKey{Name: "finetune", Namespace: "default"}, job)).To(Succeed())
+		Expect(job.Spec.ActiveDeadlineSeconds).To(HaveValue(Equal(int64(86400))))
+		Expect(job.Spec.BackoffLimit).To(HaveValue(Equal(int32(3))))
+		Expect(job.Spec.TTLSecondsAfterFinished).To(BeNil())
+
+		rules := job.Spec.PodFailurePolicy.Rules
+		Expect(rules).To(HaveLen(3))
+		Expect(rules[0].Action).To(Equal(batchv1.PodFailurePolicyActionIgnore))
+		Expect(rules[1].Action).To(Equal(batchv1.PodFailurePolicyActionFailJob))
+		Expect(rules[1].OnExitCodes.Values).To(Equal([]int32{2}))
+		Expect(rules[2].Action).To(Equal(batchv1.PodFailurePolicyActionCount))
+		Expect(rules[2].OnExitCodes.Values).To(Equal([]int32{1, 137}))
+		Expect(rules[2].OnExitCodes.ContainerName).To(HaveValue(Equal("finetune")))
+		Expect(aiJob.Spec.FailurePolicy.RetryableExitCodes).To(Equal([]int32{137, 1, 137}))
+	})
+
+	It("should reject exit codes that are both retryable and fatal", func() {
+		spec := aiv1.JobSpec{FailurePolicy: &aiv1.JobFailurePolicy{
+			RetryableExitCodes: []int32{137, 2},
+			FatalExitCodes:     []int32{2},
+		}}
+		Expect(spec.Validate()).To(MatchError(ContainSubstring("exit code 2 cannot be both retryable and fatal")))
+	})
+
+	It("should delete the batch jobs and volume once the TTL passed, keeping the secret", func() {
+		aiJob := newJob()
+		aiJob.Status.State = aiv1.JobStateSucceeded
+		objects := []client.Object{
+			&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "finetune", Namespace: "default"}},
+			&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "finetune-postprocess", Namespace: "default"}},
+			&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "finetune", Namespace: "default"}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "hf-token", Namespace: "default"}},
+		}
+		r := newReconciler(objects...)
+		Expect(r.setOwnerReference(&aiJob, objects[2])).To(Succeed())
+		Expect(r.Update(ctx, objects[2])).To(Succeed())
+
+		By("waiting for the TTL")
+		completed := metav1.NewTime(time.Now().Add(-30 * time.Second))
+		aiJob.Status.CompletionTime = &completed
+		requeueAfter, err := r.expire(ctx, aiJob)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(BeNumerically("~", 30*time.Second, 5*time.Second))
+		for _, object := range objects {
+			Expect(r.Get(ctx, client.ObjectKeyFromObject(object), object)).To(Succeed())
+		}
+
+		By("expiring the resources")
+		completed = metav1.NewTime(time.Now().Add(-time.Minute))
+		requeueAfter, err = r.expire(ctx, aiJob)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requeueAfter).To(BeZero())
+		for _, object := range objects[:3] {
+			err := r.Get(ctx, client.ObjectKeyFromObject(object), object)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue(), object.GetName())
+		}
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(objects[3]), objects[3])).To(Succeed())
+	})
+
+	It("should neither adopt nor delete the volume retained by an earlier job of the same name", func() {
+		aiJob := newJob()
+		retained := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+			Name:      "finetune",
+			Namespace: "default",
+			Labels:    map[string]string{pvcRetainedJobLabel: "finetune"},
+		}}
+		r := newReconciler(retained)
+
+		_, err := r.createPVC(ctx, aiJob)
+		var conflict *ownershipConflictError
+		Expect(err).To(BeAssignableToTypeOf(conflict))
+		Expect(err).To(MatchError("PersistentVolumeClaim finetune already exists and is not controlled by the job"))
+
+		Expect(r.releasePVC(ctx, aiJob)).To(Succeed())
+		Expect(r.Get(ctx, client.ObjectKeyFromObject(retained), retained)).To(Succeed())
+		Expect(retained.OwnerReferences).To(BeEmpty())
+	})
+
+	It("should create the batch job when it is missing", func() {
+		aiJob := newJob()
+		r := newReconciler()
+		Expect(r.suspendJob(ctx, aiJob)).To(Succeed())
+		Expect(r.Get(ctx, client.ObjectKey{Name: "finetune", Namespace: "default"}, &batchv1.Job{})).To(Succeed())
+	})
+})
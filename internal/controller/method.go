@@ -0,0 +1,16 @@
+package controller
+
+import (
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+// outputType returns the kind of weights written by the fine-tuning method
+func outputType(method *aiv1.JobMethod) aiv1.OutputType {
+	switch {
+	case !method.Adapter():
+		return aiv1.OutputTypeFull
+	case method.Merge:
+		return aiv1.OutputTypeMerged
+	}
+	return aiv1.OutputTypeAdapter
+}
@@ -0,0 +1,23 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Fine-tuning method", func() {
+	It("should write adapters", func() {
+		Expect(outputType(&aiv1.JobMethod{Type: aiv1.MethodQLoRA})).To(Equal(aiv1.OutputTypeAdapter))
+	})
+
+	It("should merge the adapters on request", func() {
+		Expect(outputType(&aiv1.JobMethod{Type: aiv1.MethodLoRA, Merge: true})).To(Equal(aiv1.OutputTypeMerged))
+	})
+
+	It("should write full weights by default", func() {
+		Expect(outputType(nil)).To(Equal(aiv1.OutputTypeFull))
+		Expect(outputType(&aiv1.JobMethod{Type: aiv1.MethodFull})).To(Equal(aiv1.OutputTypeFull))
+	})
+})
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// Label of the pods selecting the egress profile of their phase
+	jobPhaseLabel    = "ai.re-cinq.com/phase"
+	jobPhaseDownload = "download"
+	jobPhaseTraining = "training"
+)
+
+// configureNetwork labels the pod with its phase, so the network policy of
+// the phase selects it
+func configureNetwork(aiJob aiv1.Job, pod *corev1.PodTemplateSpec, phase string) {
+	if aiJob.Spec.Network == nil {
+		return
+	}
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	pod.Labels[jobPhaseLabel] = phase
+}
+
+// networkPolicies builds the policies restricting the egress of the pods of
+// the AI Job. Pods keep the download profile until the controller sees the
+// model downloaded, so it also allows the destinations of the training.
+func networkPolicies(aiJob aiv1.Job) []*networkingv1.NetworkPolicy {
+	network := aiJob.Spec.Network
+	profiles := []struct {
+		phase string
+		rules []networkingv1.NetworkPolicyEgressRule
+	}{
+		{jobPhaseDownload, append(append([]networkingv1.NetworkPolicyEgressRule{}, network.Download...), network.Training...)},
+		{jobPhaseTraining, network.Training},
+	}
+
+	// Names are resolved by the cluster DNS, wherever it runs
+	udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+	dnsPort := intstr.FromInt32(53)
+	dns := networkingv1.NetworkPolicyEgressRule{
+		Ports: []networkingv1.NetworkPolicyPort{
+			{Protocol: &udp, Port: &dnsPort},
+			{Protocol: &tcp, Port: &dnsPort},
+		},
+	}
+
+	var policies []*networkingv1.NetworkPolicy
+	for _, profile := range profiles {
+		policies = append(policies, &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%s", aiJob.Name, profile.phase),
+				Namespace: aiJob.Namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/name": aiJob.Name,
+				},
+			},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						"app.kubernetes.io/name": aiJob.Name,
+						jobPhaseLabel:            profile.phase,
+					},
+				},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+				Egress:      append([]networkingv1.NetworkPolicyEgressRule{dns}, profile.rules...),
+			},
+		})
+	}
+	return policies
+}
+
+// createNetworkPolicies creates or updates the network policies of the AI Job
+func (r *JobReconciler) createNetworkPolicies(ctx context.Context, aiJob aiv1.Job) error {
+	if aiJob.Spec.Network == nil {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+
+	for _, policy := range networkPolicies(aiJob) {
+		existing := &networkingv1.NetworkPolicy{}
+		err := r.Get(ctx, client.ObjectKeyFromObject(policy), existing)
+		switch {
+		case apierrors.IsNotFound(err):
+			if err := r.setOwnerReference(&aiJob, policy); err != nil {
+				return fmt.Errorf("failed to set owner reference: %w", err)
+			}
+			if err := r.Create(ctx, policy); err != nil {
+				logger.Error(err, "unable to create network policy", "name", policy.Name)
+				return err
+			}
+		case err != nil:
+			return err
+		case !equality.Semantic.DeepEqual(existing.Spec, policy.Spec):
+			existing.Spec = policy.Spec
+			if err := r.Update(ctx, existing); err != nil {
+				logger.Error(err, "unable to update network policy", "name", policy.Name)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// syncNetworkPhase moves the pods of the AI Job that downloaded the model to
+// the training profile
+func (r *JobReconciler) syncNetworkPhase(ctx context.Context, aiJob aiv1.Job) error {
+	if r.Clientset == nil || aiJob.Spec.Network == nil || aiJob.Status.State != aiv1.JobStateRunning {
+		return nil
+	}
+
+	// Pods are listed directly to avoid caching every pod of the cluster
+	pods, err := r.Clientset.CoreV1().Pods(aiJob.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{
+			batchv1.JobNameLabel: aiJob.Name,
+			jobPhaseLabel:        jobPhaseDownload,
+		}).String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	patch := []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`, jobPhaseLabel, jobPhaseTraining))
+	for _, pod := range pods.Items {
+		if !downloadCompleted(&pod) {
+			continue
+		}
+		if _, err := r.Clientset.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to move pod %s to the training network profile: %w", pod.Name, err)
+		}
+		log.FromContext(ctx).Info("model downloaded, restricting the egress to the training profile", "pod", pod.Name)
+	}
+	return nil
+}
+
+// downloadCompleted reports whether the init containers of the pod succeeded
+func downloadCompleted(pod *corev1.Pod) bool {
+	if len(pod.Status.InitContainerStatuses) == 0 {
+		return false
+	}
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.State.Terminated == nil || status.State.Terminated.ExitCode != 0 {
+			return false
+		}
+	}
+	return true
+}
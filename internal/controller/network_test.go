@@ -0,0 +1,73 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Network", func() {
+	download := networkingv1.NetworkPolicyEgressRule{
+		To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8"}}},
+	}
+	training := networkingv1.NetworkPolicyEgressRule{
+		To: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+	}
+	aiJob := aiv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "default"},
+		Spec: aiv1.JobSpec{Network: &aiv1.JobNetwork{
+			Download: []networkingv1.NetworkPolicyEgressRule{download},
+			Training: []networkingv1.NetworkPolicyEgressRule{training},
+		}},
+	}
+
+	It("should not label the pods without network", func() {
+		pod := &corev1.PodTemplateSpec{}
+		configureNetwork(aiv1.Job{}, pod, jobPhaseDownload)
+		Expect(pod.Labels).To(BeEmpty())
+	})
+
+	It("should label the pods with their phase", func() {
+		pod := &corev1.PodTemplateSpec{}
+		configureNetwork(aiJob, pod, jobPhaseTraining)
+		Expect(pod.Labels).To(HaveKeyWithValue(jobPhaseLabel, jobPhaseTraining))
+	})
+
+	It("should build a policy per phase", func() {
+		policies := networkPolicies(aiJob)
+		Expect(policies).To(HaveLen(2))
+
+		Expect(policies[0].Name).To(Equal("job-download"))
+		Expect(policies[0].Spec.PodSelector.MatchLabels).To(HaveKeyWithValue(jobPhaseLabel, jobPhaseDownload))
+		Expect(policies[0].Spec.PolicyTypes).To(ConsistOf(networkingv1.PolicyTypeEgress))
+		Expect(policies[0].Spec.Egress).To(HaveLen(3))
+		Expect(policies[0].Spec.Egress[0].Ports).To(HaveLen(2))
+		Expect(policies[0].Spec.Egress[1:]).To(Equal([]networkingv1.NetworkPolicyEgressRule{download, training}))
+
+		Expect(policies[1].Name).To(Equal("job-training"))
+		Expect(policies[1].Spec.PodSelector.MatchLabels).To(HaveKeyWithValue(jobPhaseLabel, jobPhaseTraining))
+		Expect(policies[1].Spec.Egress[1:]).To(Equal([]networkingv1.NetworkPolicyEgressRule{training}))
+	})
+
+	It("should detect the completed download", func() {
+		pod := &corev1.Pod{}
+		Expect(downloadCompleted(pod)).To(BeFalse())
+
+		pod.Status.InitContainerStatuses = []corev1.ContainerStatus{
+			{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		}
+		Expect(downloadCompleted(pod)).To(BeFalse())
+
+		pod.Status.InitContainerStatuses[0].State = corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{ExitCode: 1},
+		}
+		Expect(downloadCompleted(pod)).To(BeFalse())
+
+		pod.Status.InitContainerStatuses[0].State.Terminated.ExitCode = 0
+		Expect(downloadCompleted(pod)).To(BeTrue())
+	})
+})
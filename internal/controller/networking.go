@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Annotation listing the Multus networks attached to a pod
+	multusNetworksAnnotation = "k8s.v1.cni.cncf.io/networks"
+)
+
+// configureNetworking attaches the secondary networks and RDMA devices to the
+// training pod and tunes NCCL
+func configureNetworking(aiJob aiv1.Job, pod *corev1.PodTemplateSpec) {
+	networking := aiJob.Spec.Networking
+	if networking == nil {
+		return
+	}
+	container := &pod.Spec.Containers[0]
+
+	if len(networking.Attachments) > 0 {
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[multusNetworksAnnotation] = strings.Join(networking.Attachments, ",")
+	}
+
+	if len(networking.RDMAResources) > 0 {
+		if container.Resources.Limits == nil {
+			container.Resources.Limits = corev1.ResourceList{}
+		}
+		maps.Copy(container.Resources.Limits, networking.RDMAResources)
+
+		// Registering the RDMA buffers locks memory, allowed unless the job
+		// sets the capabilities itself
+		if (aiJob.Spec.SecurityContext == nil || aiJob.Spec.SecurityContext.Capabilities == nil) &&
+			container.SecurityContext != nil {
+			container.SecurityContext.Capabilities.Add = append(container.SecurityContext.Capabilities.Add, "IPC_LOCK")
+		}
+	}
+
+	if networking.HostNetwork {
+		pod.Spec.HostNetwork = true
+		pod.Spec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+
+	if nccl := networking.NCCL; nccl != nil {
+		env := map[string]string{
+			"NCCL_SOCKET_IFNAME": nccl.SocketInterface,
+			"NCCL_IB_HCA":        nccl.IBHCA,
+			"NCCL_DEBUG":         string(nccl.DebugLevel),
+		}
+		if nccl.IBDisable {
+			env["NCCL_IB_DISABLE"] = "1"
+		}
+		for _, name := range []string{"NCCL_SOCKET_IFNAME", "NCCL_IB_HCA", "NCCL_IB_DISABLE", "NCCL_DEBUG"} {
+			overridden := slices.ContainsFunc(nccl.Env, func(e corev1.EnvVar) bool { return e.Name == name })
+			if env[name] != "" && !overridden {
+				container.Env = append(container.Env, corev1.EnvVar{Name: name, Value: env[name]})
+			}
+		}
+		container.Env = append(container.Env, nccl.Env...)
+	}
+}
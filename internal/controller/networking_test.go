@@ -0,0 +1,73 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Networking", func() {
+	newPod := func(aiJob aiv1.Job) *corev1.PodTemplateSpec {
+		pod := &corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app.kubernetes.io/name": "job"}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "job"}}},
+		}
+		configureSecurity(aiJob, &pod.Spec)
+		return pod
+	}
+
+	It("should leave the pod unchanged without networking", func() {
+		pod := newPod(aiv1.Job{})
+		expected := pod.DeepCopy()
+		configureNetworking(aiv1.Job{}, pod)
+		Expect(pod).To(Equal(expected))
+	})
+
+	It("should attach the networks and RDMA devices", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{Networking: &aiv1.JobNetworking{
+			Attachments:   []string{"net-a", "fabric/net-b"},
+			RDMAResources: corev1.ResourceList{"rdma/hca": resource.MustParse("1")},
+			HostNetwork:   true,
+		}}}
+		pod := newPod(aiJob)
+		configureNetworking(aiJob, pod)
+
+		Expect(pod.Annotations).To(HaveKeyWithValue(multusNetworksAnnotation, "net-a,fabric/net-b"))
+		Expect(pod.Spec.Containers[0].Resources.Limits).To(HaveKey(corev1.ResourceName("rdma/hca")))
+		Expect(pod.Spec.Containers[0].SecurityContext.Capabilities.Add).To(ConsistOf(corev1.Capability("IPC_LOCK")))
+		Expect(pod.Spec.HostNetwork).To(BeTrue())
+		Expect(pod.Spec.DNSPolicy).To(Equal(corev1.DNSClusterFirstWithHostNet))
+	})
+
+	It("should not use the host network with network isolation", func() {
+		spec := aiv1.JobSpec{
+			HuggingFaceSecret: "token",
+			Networking:        &aiv1.JobNetworking{HostNetwork: true},
+			Network:           &aiv1.JobNetwork{},
+		}
+		Expect(spec.Validate()).To(MatchError(ContainSubstring("networking.hostNetwork cannot be used with network")))
+	})
+
+	It("should tune NCCL", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{Networking: &aiv1.JobNetworking{NCCL: &aiv1.JobNCCL{
+			SocketInterface: "net1",
+			IBHCA:           "mlx5_0:1",
+			DebugLevel:      aiv1.NCCLDebugInfo,
+			Env:             []corev1.EnvVar{{Name: "NCCL_DEBUG", Value: "WARN"}, {Name: "NCCL_P2P_LEVEL", Value: "NVL"}},
+		}}}}
+		pod := newPod(aiJob)
+		configureNetworking(aiJob, pod)
+
+		Expect(pod.Spec.Containers[0].Env).To(ContainElements(
+			corev1.EnvVar{Name: "NCCL_SOCKET_IFNAME", Value: "net1"},
+			corev1.EnvVar{Name: "NCCL_IB_HCA", Value: "mlx5_0:1"},
+			corev1.EnvVar{Name: "NCCL_DEBUG", Value: "WARN"},
+			corev1.EnvVar{Name: "NCCL_P2P_LEVEL", Value: "NVL"},
+		))
+		Expect(pod.Spec.Containers[0].Env).NotTo(ContainElement(corev1.EnvVar{Name: "NCCL_DEBUG", Value: "INFO"}))
+	})
+})
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/notification"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// Delivery attempts before a notification is given up
+	notificationMaxAttempts = 6
+
+	// Delay before the first retry, doubled after each failed attempt
+	notificationInitialBackoff = time.Second * 10
+	notificationMaxBackoff     = time.Minute * 10
+
+	// Time an attempt may take, a reconcile makes at most one attempt per
+	// notification and retries are scheduled with a requeue
+	notificationTimeout = time.Second * 5
+
+	// Key of the signing secret holding the HMAC key
+	notificationSigningKey = "key"
+)
+
+// notify delivers the notifications of the current state of the AI Job that
+// were not delivered yet, and records the deliveries in the status. Returns
+// how long to wait before the next retry.
+func (r *JobReconciler) notify(ctx context.Context, aiJob *aiv1.Job) time.Duration {
+	if aiJob.Status.State == "" {
+		return 0
+	}
+
+	var requeueAfter time.Duration
+	statuses := make([]aiv1.NotificationStatus, 0, len(aiJob.Spec.Notifications))
+	for _, spec := range aiJob.Spec.Notifications {
+		status := aiv1.NotificationStatus{Name: spec.Name}
+		if i := slices.IndexFunc(aiJob.Status.Notifications, func(s aiv1.NotificationStatus) bool {
+			return s.Name == spec.Name
+		}); i >= 0 {
+			status = aiJob.Status.Notifications[i]
+		}
+
+		requeueAfter = shortestRequeue(requeueAfter, r.deliver(ctx, *aiJob, spec, &status))
+		statuses = append(statuses, status)
+	}
+
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	aiJob.Status.Notifications = statuses
+	return requeueAfter
+}
+
+// deliver sends the notification when the state of the AI Job is one of its
+// events and it was not delivered yet. A failed attempt is not retried in place
+// to keep the worker free, the delay before the next retry is returned instead.
+func (r *JobReconciler) deliver(ctx context.Context, aiJob aiv1.Job, spec aiv1.JobNotification, status *aiv1.NotificationStatus) time.Duration {
+	state := aiJob.Status.State
+	if !slices.Contains(spec.Events, state) {
+		return 0
+	}
+
+	// Start over for a new state
+	if status.State != state {
+		*status = aiv1.NotificationStatus{Name: spec.Name, State: state}
+	}
+	if status.Delivered || status.Attempts >= notificationMaxAttempts {
+		return 0
+	}
+
+	// Wait for the backoff of the previous attempt
+	if status.LastAttemptTime != nil {
+		if remaining := time.Until(status.LastAttemptTime.Add(notificationBackoff(status.Attempts))); remaining > 0 {
+			return remaining
+		}
+	}
+
+	now := metav1.Now()
+	status.Attempts++
+	status.LastAttemptTime = &now
+
+	err := r.sendNotification(ctx, aiJob, spec, now.Time)
+	if err == nil {
+		status.Delivered = true
+		status.Message = ""
+		log.FromContext(ctx).Info("notification delivered", "notification", spec.Name, "state", state)
+		return 0
+	}
+
+	status.Message = err.Error()
+	if status.Attempts >= notificationMaxAttempts {
+		log.FromContext(ctx).Error(err, "giving up notification", "notification", spec.Name, "state", state)
+		return 0
+	}
+	return notificationBackoff(status.Attempts)
+}
+
+// sendNotification posts the event of the current state to the endpoint
+func (r *JobReconciler) sendNotification(ctx context.Context, aiJob aiv1.Job, spec aiv1.JobNotification, now time.Time) error {
+	webhook := &notification.Webhook{
+		URL:      spec.URL,
+		Format:   notification.Format(spec.Format),
+		Template: spec.Template,
+	}
+	if spec.SigningSecret != "" {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, client.ObjectKey{Name: spec.SigningSecret, Namespace: aiJob.Namespace}, secret); err != nil {
+			return fmt.Errorf("failed to get signing secret: %w", err)
+		}
+		webhook.SigningKey = secret.Data[notificationSigningKey]
+		if len(webhook.SigningKey) == 0 {
+			return fmt.Errorf("signing secret %s has no %q key", spec.SigningSecret, notificationSigningKey)
+		}
+	}
+
+	event := notification.Event{
+		// Retries share the ID, which changes when the spec does, like on resume
+		ID:        fmt.Sprintf("%s-%d-%s", aiJob.UID, aiJob.Generation, aiJob.Status.State),
+		Job:       aiJob.Name,
+		Namespace: aiJob.Namespace,
+		UID:       string(aiJob.UID),
+		State:     string(aiJob.Status.State),
+		Details:   aiJob.Status.Details,
+		Model:     aiJob.Spec.Model,
+		Time:      now,
+	}
+	if aiJob.Status.Tracking != nil {
+		event.RunURL = aiJob.Status.Tracking.RunURL
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, notificationTimeout)
+	defer cancel()
+	return webhook.Send(ctx, event)
+}
+
+// notificationBackoff returns the delay after the given number of attempts
+func notificationBackoff(attempts int32) time.Duration {
+	backoff := notificationInitialBackoff
+	for i := int32(1); i < attempts && backoff < notificationMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, notificationMaxBackoff)
+}
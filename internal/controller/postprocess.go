@@ -0,0 +1,318 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/backend"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// Directory of the job volume receiving the step outputs
+	postprocessOutputDir = "postprocess"
+
+	// States of the postprocess steps
+	postprocessStepPending   = "Pending"
+	postprocessStepRunning   = "Running"
+	postprocessStepSucceeded = "Succeeded"
+	postprocessStepFailed    = "Failed"
+)
+
+// postprocessWrapper runs the command of a step. It resolves the checkpoint
+// of the input with the highest number into MODEL_DIR, and lists the output
+// files with their size as termination message.
+const postprocessWrapper = `set -e
+MODEL_DIR="$INPUT_DIR"
+latest=-1
+for dir in ${CHECKPOINT_PATTERN:+"$INPUT_DIR"/$CHECKPOINT_PATTERN}; do
+  [ -d "$dir" ] || continue
+  number="${dir##*[!0-9]}"
+  if [ -n "$number" ] && [ "$number" -gt "$latest" ]; then latest="$number"; MODEL_DIR="$dir"; fi
+done
+export MODEL_DIR
+rm -rf "$OUTPUT_DIR"
+mkdir -p "$OUTPUT_DIR"
+"$@"
+cd "$OUTPUT_DIR"
+find . -type f | while read -r file; do echo "$(($(wc -c < "$file"))) ${file#./}"; done > /dev/termination-log
+`
+
+// postprocessMergeScript merges PEFT adapters into the base model
+const postprocessMergeScript = `import os
+from peft import PeftModel
+from transformers import AutoModelForCausalLM, AutoTokenizer
+
+base = AutoModelForCausalLM.from_pretrained(os.environ["BASE_MODEL_DIR"], torch_dtype="auto")
+model = PeftModel.from_pretrained(base, os.environ["MODEL_DIR"]).merge_and_unload()
+model.save_pretrained(os.environ["OUTPUT_DIR"])
+AutoTokenizer.from_pretrained(os.environ["BASE_MODEL_DIR"]).save_pretrained(os.environ["OUTPUT_DIR"])
+`
+
+// postprocessQuantizeScript quantizes the weights to 4 bits with LLM Compressor
+const postprocessQuantizeScript = `import os
+from llmcompressor import oneshot
+from llmcompressor.modifiers.awq import AWQModifier
+from llmcompressor.modifiers.quantization import GPTQModifier
+
+modifier = AWQModifier if os.environ["SCHEME"] == "awq" else GPTQModifier
+oneshot(
+    model=os.environ["MODEL_DIR"],
+    dataset="open_platypus",
+    recipe=modifier(targets="Linear", scheme="W4A16", ignore=["lm_head"]),
+    output_dir=os.environ["OUTPUT_DIR"],
+    max_seq_length=2048,
+    num_calibration_samples=256,
+)
+`
+
+// postprocessGGUFScript converts the weights with the llama.cpp tools
+const postprocessGGUFScript = `python3 /app/convert_hf_to_gguf.py "$MODEL_DIR" --outtype f16 --outfile "$OUTPUT_DIR/model-f16.gguf"
+if [ "$SCHEME" != "f16" ]; then
+  /app/llama-quantize "$OUTPUT_DIR/model-f16.gguf" "$OUTPUT_DIR/model-$SCHEME.gguf" "$SCHEME"
+  rm "$OUTPUT_DIR/model-f16.gguf"
+fi
+`
+
+// syncPostprocess runs the postprocess steps once the training completed, and
+// derives the AI Job state from the postprocess job
+func (r *JobReconciler) syncPostprocess(ctx context.Context, aiJob *aiv1.Job) error {
+	job := &batchv1.Job{}
+	err := r.Get(ctx, client.ObjectKey{Name: postprocessJobName(*aiJob), Namespace: aiJob.Namespace}, job)
+	if apierrors.IsNotFound(err) {
+		trainingBackend, err := backend.Get(aiJob.Spec.Framework)
+		if err != nil {
+			return err
+		}
+		job = postprocessJob(*aiJob, trainingBackend.CheckpointPattern())
+		configureEgress(r.jobEgress(*aiJob), &job.Spec.Template.Spec)
+		configureWorkspace(*aiJob, &job.Spec.Template.Spec)
+		configureImages(*aiJob, &job.Spec.Template.Spec)
+		configureSecurity(*aiJob, &job.Spec.Template.Spec)
+		configureServiceAccount(*aiJob, &job.Spec.Template)
+		configureNetwork(*aiJob, &job.Spec.Template, jobPhaseTraining)
+		configureVolumes(*aiJob, &job.Spec.Template.Spec, job.Spec.Template.Spec.InitContainers, job.Spec.Template.Spec.Containers)
+		r.Config.Get().Schedule(&job.Spec.Template.Spec)
+		if err := r.setOwnerReference(aiJob, job); err != nil {
+			return fmt.Errorf("failed to set owner reference: %w", err)
+		}
+		log.FromContext(ctx).Info("starting postprocess steps")
+		if err := r.Create(ctx, job); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if len(aiJob.Status.Postprocess) != len(aiJob.Spec.Postprocess) {
+		aiJob.Status.Postprocess = nil
+		for _, step := range aiJob.Spec.Postprocess {
+			aiJob.Status.Postprocess = append(aiJob.Status.Postprocess, aiv1.PostprocessStepStatus{
+				Name:  step.Name,
+				State: postprocessStepPending,
+				Path:  path.Join(postprocessOutputDir, step.Name),
+			})
+		}
+	}
+	if err := r.syncPostprocessSteps(ctx, aiJob); err != nil {
+		log.FromContext(ctx).Error(err, "failed to read postprocess steps")
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			aiJob.Status.State = aiv1.JobStateSucceeded
+			aiJob.Status.Details = condition.Message
+			aiJob.Status.CompletionTime = &condition.LastTransitionTime
+			return nil
+		case batchv1.JobFailed:
+			aiJob.Status.State = aiv1.JobStateFailed
+			aiJob.Status.Details = fmt.Sprintf("Postprocess failed: %s", condition.Message)
+			aiJob.Status.CompletionTime = &condition.LastTransitionTime
+			return nil
+		}
+	}
+
+	aiJob.Status.State = aiv1.JobStateRunning
+	aiJob.Status.Details = "Training completed, running the postprocess steps"
+	return nil
+}
+
+// syncPostprocessSteps records the state and outputs of the steps from the
+// containers of the postprocess pod
+func (r *JobReconciler) syncPostprocessSteps(ctx context.Context, aiJob *aiv1.Job) error {
+	if r.Clientset == nil {
+		return nil
+	}
+
+	pod, err := r.latestJobPod(ctx, aiJob.Namespace, postprocessJobName(*aiJob))
+	if err != nil || pod == nil {
+		return err
+	}
+
+	statuses := slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses)
+	for i := range aiJob.Status.Postprocess {
+		step := &aiJob.Status.Postprocess[i]
+		for _, status := range statuses {
+			if status.Name != step.Name {
+				continue
+			}
+			switch {
+			case status.State.Running != nil:
+				step.State = postprocessStepRunning
+			case status.State.Terminated != nil && status.State.Terminated.ExitCode == 0:
+				step.State = postprocessStepSucceeded
+				step.Size, step.Files = parseOutputListing(status.State.Terminated.Message)
+			case status.State.Terminated != nil:
+				step.State = postprocessStepFailed
+			}
+		}
+	}
+	return nil
+}
+
+// postprocessJob builds the batch job running the steps in order, all but the
+// last one as init containers. Only the training output is searched for the
+// checkpoints matching the pattern of the framework.
+func postprocessJob(aiJob aiv1.Job, checkpointPattern string) *batchv1.Job {
+	backoffLimit := int32(0)
+	volumeMounts := []corev1.VolumeMount{workspaceVolumeMount(aiJob)}
+
+	var containers []corev1.Container
+	for i, step := range aiJob.Spec.Postprocess {
+		input := workspacePath(aiJob, jobWorkspace(aiJob).Output)
+		pattern := checkpointPattern
+		switch {
+		case step.Input != "":
+			input = workspacePath(aiJob, postprocessOutputDir, step.Input)
+			pattern = ""
+		case i > 0:
+			input = workspacePath(aiJob, postprocessOutputDir, aiJob.Spec.Postprocess[i-1].Name)
+			pattern = ""
+		}
+
+		container := corev1.Container{
+			Name:    step.Name,
+			Image:   step.Image,
+			Command: append([]string{"sh", "-c", postprocessWrapper, "sh"}, postprocessCommand(step)...),
+			Env: []corev1.EnvVar{
+				{Name: "INPUT_DIR", Value: input},
+				{Name: "OUTPUT_DIR", Value: workspacePath(aiJob, postprocessOutputDir, step.Name)},
+				{Name: "BASE_MODEL_DIR", Value: jobModelDir(aiJob)},
+				{Name: "CHECKPOINT_PATTERN", Value: pattern},
+				{Name: "SCHEME", Value: step.Scheme},
+				{Name: "PYTHONUNBUFFERED", Value: "1"},
+			},
+			VolumeMounts: volumeMounts,
+		}
+		// Merging and calibrating run the model
+		if aiJob.Spec.GPUs > 0 && (step.Type == aiv1.PostprocessMerge || step.Type == aiv1.PostprocessQuantize) {
+			container.Resources.Limits = corev1.ResourceList{
+				jobGPUResourceName: *resource.NewQuantity(int64(aiJob.Spec.GPUs), resource.DecimalSI),
+			}
+		}
+		containers = append(containers, container)
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postprocessJobName(aiJob),
+			Namespace: aiJob.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":      aiJob.Name,
+				"app.kubernetes.io/component": "postprocess",
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"app.kubernetes.io/name":      aiJob.Name,
+						"app.kubernetes.io/component": "postprocess",
+					},
+				},
+				Spec: corev1.PodSpec{
+					RuntimeClassName: &aiJob.Spec.RuntimeClassName,
+					RestartPolicy:    corev1.RestartPolicyNever,
+					NodeSelector:     maps.Clone(aiJob.Spec.NodeSelector),
+					Tolerations:      slices.Clone(aiJob.Spec.Tolerations),
+					InitContainers:   containers[:len(containers)-1],
+					Containers:       containers[len(containers)-1:],
+					Volumes: []corev1.Volume{
+						{
+							Name: jobDefaultVolumeName,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: aiJob.Name,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// postprocessCommand returns the command run by the wrapper for the step
+func postprocessCommand(step aiv1.PostprocessStep) []string {
+	switch step.Type {
+	case aiv1.PostprocessMerge:
+		return []string{"python", "-c", postprocessMergeScript}
+	case aiv1.PostprocessQuantize:
+		return []string{"python", "-c", postprocessQuantizeScript}
+	case aiv1.PostprocessConvertGGUF:
+		return []string{"sh", "-c", postprocessGGUFScript}
+	}
+	return step.Command
+}
+
+// parseOutputListing reads the "<size> <file>" lines written by the wrapper.
+// The termination message is truncated by the kubelet for large outputs.
+func parseOutputListing(message string) (int64, []string) {
+	var size int64
+	var files []string
+	for _, line := range strings.Split(strings.TrimSpace(message), "\n") {
+		fileSize, file, found := strings.Cut(line, " ")
+		bytes, err := strconv.ParseInt(fileSize, 10, 64)
+		if !found || err != nil {
+			continue
+		}
+		size += bytes
+		files = append(files, file)
+	}
+	return size, files
+}
+
+// deletePostprocessJob deletes the postprocess job and its pods
+func (r *JobReconciler) deletePostprocessJob(ctx context.Context, aiJob aiv1.Job) error {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      postprocessJobName(aiJob),
+			Namespace: aiJob.Namespace,
+		},
+	}
+	err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground))
+	return client.IgnoreNotFound(err)
+}
+
+// postprocessJobName returns the name of the batch job running the postprocess steps
+func postprocessJobName(aiJob aiv1.Job) string {
+	return aiJob.Name + "-postprocess"
+}
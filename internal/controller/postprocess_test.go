@@ -0,0 +1,64 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Postprocess", func() {
+	It("should chain the steps on the job volume", func() {
+		aiJob := aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "finetune", Namespace: "default"},
+			Spec: aiv1.JobSpec{
+				HuggingFaceSecret: "token",
+				Postprocess: []aiv1.PostprocessStep{
+					{Name: "merge", Type: aiv1.PostprocessMerge},
+					{Name: "gguf", Type: aiv1.PostprocessConvertGGUF},
+					{Name: "awq", Type: aiv1.PostprocessQuantize, Scheme: "awq", Input: "merge"},
+				},
+			},
+		}
+		Expect(aiJob.Spec.Validate()).To(Succeed())
+
+		job := postprocessJob(aiJob, "epoch_*")
+		Expect(job.Name).To(Equal("finetune-postprocess"))
+
+		pod := job.Spec.Template.Spec
+		Expect(pod.InitContainers).To(HaveLen(2))
+		Expect(pod.Containers).To(HaveLen(1))
+		Expect(pod.InitContainers[0].Env).To(ContainElements(
+			corev1.EnvVar{Name: "INPUT_DIR", Value: "/workspace/output"},
+			corev1.EnvVar{Name: "OUTPUT_DIR", Value: "/workspace/postprocess/merge"},
+			corev1.EnvVar{Name: "BASE_MODEL_DIR", Value: "/workspace/model"},
+			corev1.EnvVar{Name: "CHECKPOINT_PATTERN", Value: "epoch_*"},
+		))
+		Expect(pod.InitContainers[1].Image).To(Equal("ghcr.io/ggml-org/llama.cpp:full"))
+		Expect(pod.InitContainers[1].Env).To(ContainElements(
+			corev1.EnvVar{Name: "INPUT_DIR", Value: "/workspace/postprocess/merge"},
+			corev1.EnvVar{Name: "SCHEME", Value: "q4_k_m"},
+			corev1.EnvVar{Name: "CHECKPOINT_PATTERN", Value: ""},
+		))
+		Expect(pod.Containers[0].Env).To(ContainElement(corev1.EnvVar{Name: "INPUT_DIR", Value: "/workspace/postprocess/merge"}))
+	})
+
+	It("should reject steps reading from later steps", func() {
+		spec := aiv1.JobSpec{
+			HuggingFaceSecret: "token",
+			Postprocess: []aiv1.PostprocessStep{
+				{Name: "gguf", Type: aiv1.PostprocessConvertGGUF, Input: "merge"},
+				{Name: "merge", Type: aiv1.PostprocessMerge},
+			},
+		}
+		Expect(spec.Validate()).To(MatchError(ContainSubstring("earlier step")))
+	})
+
+	It("should parse the output listing", func() {
+		size, files := parseOutputListing("1024 model-q4_k_m.gguf\n12 config/params.json\n")
+		Expect(size).To(Equal(int64(1036)))
+		Expect(files).To(Equal([]string{"model-q4_k_m.gguf", "config/params.json"}))
+	})
+})
@@ -0,0 +1,136 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/backend"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// Minimum time between two reads of the training logs
+	jobProgressInterval = time.Second * 30
+
+	// Amount of logs kept to find the latest progress
+	jobProgressLogLines = 100
+	jobProgressLogBytes = 1024 * 1024
+)
+
+// syncProgress reads the training logs of a running AI Job at most once per
+// jobProgressInterval and records the parsed progress in its status
+func (r *JobReconciler) syncProgress(ctx context.Context, aiJob *aiv1.Job) error {
+	if r.Clientset == nil || aiJob.Status.State != aiv1.JobStateRunning {
+		return nil
+	}
+
+	previous := aiJob.Status.Progress
+	if previous != nil && time.Since(previous.LastUpdateTime.Time) < jobProgressInterval {
+		return nil
+	}
+
+	pod, err := r.latestJobPod(ctx, aiJob.Namespace, aiJob.Name)
+	if err != nil || pod == nil {
+		return err
+	}
+	if !containerRunning(pod, aiJob.Name) {
+		return nil
+	}
+
+	// Progress bars redraw their line with \r, so a single line can hold the
+	// whole epoch. Only the logs written since the previous read are fetched,
+	// and only their end is kept.
+	lines := int64(jobProgressLogLines)
+	logOptions := &corev1.PodLogOptions{
+		Container: aiJob.Name,
+		TailLines: &lines,
+	}
+	if previous != nil {
+		since := metav1.NewTime(previous.LastUpdateTime.Add(-jobProgressInterval))
+		logOptions.SinceTime = &since
+	}
+	stream, err := r.Clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, logOptions).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read training logs: %w", err)
+	}
+	defer func() { _ = stream.Close() }()
+
+	logs, err := lastBytes(stream, jobProgressLogBytes)
+	if err != nil {
+		return err
+	}
+
+	trainingBackend, err := backend.Get(aiJob.Spec.Framework)
+	if err != nil {
+		return err
+	}
+	progress := trainingBackend.ParseProgress(*aiJob, string(logs))
+	if progress == nil {
+		return nil
+	}
+	if previous != nil && progress.TokensPerSecond == "" {
+		progress.TokensPerSecond = previous.TokensPerSecond
+	}
+	progress.LastUpdateTime = metav1.Now()
+	aiJob.Status.Progress = progress
+
+	log.FromContext(ctx).V(1).Info("training progress", "step", progress.Step, "percent", progress.Percent)
+	return nil
+}
+
+// lastBytes reads r to its end and returns its last n bytes
+func lastBytes(r io.Reader, n int) ([]byte, error) {
+	buf := make([]byte, 0, 2*n)
+	chunk := make([]byte, 32*1024)
+	for {
+		read, err := r.Read(chunk)
+		buf = append(buf, chunk[:read]...)
+		if len(buf) > 2*n {
+			buf = append(buf[:0], buf[len(buf)-n:]...)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf[max(0, len(buf)-n):], nil
+}
+
+// latestJobPod returns the most recent pod of the batch job, or nil when there is none
+func (r *JobReconciler) latestJobPod(ctx context.Context, namespace, jobName string) (*corev1.Pod, error) {
+	// Pods are listed directly to avoid caching every pod of the cluster
+	pods, err := r.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(labels.Set{batchv1.JobNameLabel: jobName}).String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *corev1.Pod
+	for i := range pods.Items {
+		if latest == nil || pods.Items[i].CreationTimestamp.After(latest.CreationTimestamp.Time) {
+			latest = &pods.Items[i]
+		}
+	}
+	return latest, nil
+}
+
+// containerRunning reports whether the container of the pod is running
+func containerRunning(pod *corev1.Pod, container string) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container {
+			return status.State.Running != nil
+		}
+	}
+	return false
+}
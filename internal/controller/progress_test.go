@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"testing/iotest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/backend"
+)
+
+var _ = Describe("Progress", func() {
+	It("should keep the end of the logs", func() {
+		logs, err := lastBytes(strings.NewReader("0123456789"), 4)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(logs)).To(Equal("6789"))
+
+		logs, err = lastBytes(iotest.HalfReader(strings.NewReader("short")), 16)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(logs)).To(Equal("short"))
+	})
+
+	It("should parse the latest redraw of a progress bar longer than the kept logs", func() {
+		var redraws strings.Builder
+		redraws.WriteString("Step 1 | loss:2.5 lr:2e-05 tokens_per_second_per_gpu:1523.4\n")
+		const steps = 20000
+		for step := 1; step <= steps; step++ {
+			fmt.Fprintf(&redraws, "\r1|%d|Loss: %.4f:  %d%%|█████▏    | %d/%d [00:15<00:14,  1.20s/it]",
+				step, 1+1/float64(step), step*100/steps, step, steps)
+		}
+		Expect(redraws.Len()).To(BeNumerically(">", jobProgressLogBytes))
+
+		logs, err := lastBytes(strings.NewReader(redraws.String()), jobProgressLogBytes)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(logs).To(HaveLen(jobProgressLogBytes))
+
+		trainingBackend, err := backend.Get(aiv1.FrameworkTorchtune)
+		Expect(err).NotTo(HaveOccurred())
+		progress := trainingBackend.ParseProgress(aiv1.Job{}, string(logs))
+		Expect(progress).NotTo(BeNil())
+		Expect(progress.Step).To(Equal(int64(steps)))
+		Expect(progress.Percent).To(Equal(int32(100)))
+		Expect(progress.Loss).To(Equal("1.0001"))
+	})
+})
@@ -3,6 +3,8 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	aiv1 "github.com/re-cinq/ai-operator/api/v1"
@@ -10,10 +12,21 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+const (
+	// Labels set on volumes retained after their job is gone
+	pvcRetainedJobLabel            = "ai.re-cinq.com/job"
+	pvcRetainedModelLabel          = "ai.re-cinq.com/model"
+	pvcRetainedCompletionTimeLabel = "ai.re-cinq.com/completion-time"
+
+	// Annotation holding the unmodified model name of a retained volume
+	pvcRetainedModelAnnotation = "ai.re-cinq.com/model"
+)
+
 func (r *JobReconciler) createPVC(ctx context.Context, aiJob aiv1.Job) (bool, error) {
 	logger := log.FromContext(ctx)
 
@@ -43,20 +56,24 @@ func (r *JobReconciler) createPVC(ctx context.Context, aiJob aiv1.Job) (bool, er
 		requestedStorageClassName = "local-path"
 	}
 
+	requestedSize := resource.NewQuantity(int64(aiJob.Spec.DiskSize)*1024*1024*1024, resource.BinarySI)
+	pvc.Spec = corev1.PersistentVolumeClaimSpec{
+		StorageClassName: &requestedStorageClassName,
+		AccessModes:      accessModes,
+		Resources: corev1.VolumeResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceStorage: *requestedSize,
+			},
+		},
+		DataSource: volumeSnapshotDataSource(aiJob),
+	}
+
 	// Check if PVC exists
-	err := r.Get(ctx, client.ObjectKeyFromObject(pvc), pvc)
+	existing := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(pvc), existing)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
 			// Create new PVC
-			pvc.Spec = corev1.PersistentVolumeClaimSpec{
-				StorageClassName: &requestedStorageClassName,
-				AccessModes:      accessModes,
-				Resources: corev1.VolumeResourceRequirements{
-					Requests: corev1.ResourceList{
-						corev1.ResourceStorage: *resource.NewQuantity(int64(aiJob.Spec.DiskSize)*1024*1024*1024, resource.BinarySI),
-					},
-				},
-			}
 			if err := r.Create(ctx, pvc); err != nil {
 				logger.Error(err, "unable to create PVC")
 				return false, err
@@ -67,13 +84,20 @@ func (r *JobReconciler) createPVC(ctx context.Context, aiJob aiv1.Job) (bool, er
 		return false, err
 	}
 
+	// A volume retained by an earlier job of the same name, or created by
+	// others, is never adopted nor deleted with its data
+	if !metav1.IsControlledBy(existing, &aiJob) {
+		return false, &ownershipConflictError{kind: "PersistentVolumeClaim", name: existing.Name}
+	}
+
 	// Check if size has changed
-	currentSize := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
-	requestedSize := resource.NewQuantity(int64(aiJob.Spec.DiskSize)*1024*1024*1024, resource.BinarySI)
-	requestedSize.Add(currentSize)
+	currentSize := existing.Spec.Resources.Requests[corev1.ResourceStorage]
 
 	// Check if the storage class has changed
-	currentStorageClassName := *pvc.Spec.StorageClassName
+	currentStorageClassName := ""
+	if existing.Spec.StorageClassName != nil {
+		currentStorageClassName = *existing.Spec.StorageClassName
+	}
 
 	if currentSize.Cmp(*requestedSize) != 0 || requestedStorageClassName != currentStorageClassName {
 		// Delete existing PVC
@@ -82,7 +106,6 @@ func (r *JobReconciler) createPVC(ctx context.Context, aiJob aiv1.Job) (bool, er
 		}
 
 		// Create new PVC with updated size
-		pvc.Spec.Resources.Requests[corev1.ResourceStorage] = *requestedSize
 		if err := r.Create(ctx, pvc); err != nil {
 			logger.Error(err, "unable to create PVC with new size")
 			return false, err
@@ -93,6 +116,9 @@ func (r *JobReconciler) createPVC(ctx context.Context, aiJob aiv1.Job) (bool, er
 	return false, nil
 }
 
+// deletePVC deletes the PVC of the AI Job and waits until it is gone. Volumes
+// the job does not control, like those retained by an earlier job of the
+// same name, are left untouched.
 func (r *JobReconciler) deletePVC(ctx context.Context, aiJob aiv1.Job) error {
 	logger := log.FromContext(ctx)
 	pvc := &corev1.PersistentVolumeClaim{
@@ -102,7 +128,15 @@ func (r *JobReconciler) deletePVC(ctx context.Context, aiJob aiv1.Job) error {
 		},
 	}
 
-	if err := r.Delete(ctx, pvc); err != nil {
+	if err := r.Get(ctx, client.ObjectKeyFromObject(pvc), pvc); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(pvc, &aiJob) {
+		return nil
+	}
+
+	uid := pvc.UID
+	if err := r.Delete(ctx, pvc, client.Preconditions{UID: &uid}); err != nil {
 		if !apierrors.IsNotFound(err) {
 			logger.Error(err, "unable to delete PVC")
 			return err
@@ -113,6 +147,67 @@ func (r *JobReconciler) deletePVC(ctx context.Context, aiJob aiv1.Job) error {
 	return r.waitForPVCDeletion(ctx, aiJob)
 }
 
+// releasePVC deletes the PVC of the AI Job, unless its retention policy keeps it
+func (r *JobReconciler) releasePVC(ctx context.Context, aiJob aiv1.Job) error {
+	switch aiJob.Spec.RetentionPolicy {
+	case aiv1.RetentionPolicyRetain:
+		return r.retainPVC(ctx, aiJob)
+	case aiv1.RetentionPolicyRetainOnSuccess:
+		if aiJob.Status.State == aiv1.JobStateSucceeded {
+			return r.retainPVC(ctx, aiJob)
+		}
+	}
+	return r.deletePVC(ctx, aiJob)
+}
+
+// retainPVC removes the owner reference of the AI Job from the PVC, so that it
+// is not garbage collected, and labels it for later reuse or cleanup
+func (r *JobReconciler) retainPVC(ctx context.Context, aiJob aiv1.Job) error {
+	logger := log.FromContext(ctx)
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, client.ObjectKey{Name: aiJob.Name, Namespace: aiJob.Namespace}, pvc); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	patch := client.MergeFrom(pvc.DeepCopy())
+	pvc.OwnerReferences = slices.DeleteFunc(pvc.OwnerReferences, func(ref metav1.OwnerReference) bool {
+		return ref.UID == aiJob.UID
+	})
+	if pvc.Labels == nil {
+		pvc.Labels = map[string]string{}
+	}
+	pvc.Labels[pvcRetainedJobLabel] = aiJob.Name
+	pvc.Labels[pvcRetainedModelLabel] = labelValue(aiJob.Spec.Model)
+	if aiJob.Status.CompletionTime != nil {
+		pvc.Labels[pvcRetainedCompletionTimeLabel] = aiJob.Status.CompletionTime.UTC().Format("20060102T150405Z")
+	}
+	if pvc.Annotations == nil {
+		pvc.Annotations = map[string]string{}
+	}
+	pvc.Annotations[pvcRetainedModelAnnotation] = aiJob.Spec.Model
+
+	if err := r.Patch(ctx, pvc, patch); err != nil {
+		logger.Error(err, "unable to retain PVC")
+		return err
+	}
+	return nil
+}
+
+// labelValue turns a value like a Hugging Face repository into a valid label value
+func labelValue(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '.'
+	}, value)
+	if len(value) > validation.LabelValueMaxLength {
+		value = value[:validation.LabelValueMaxLength]
+	}
+	return strings.Trim(value, "-_.")
+}
+
 func (r *JobReconciler) waitForPVCDeletion(ctx context.Context, aiJob aiv1.Job) error {
 	// Wait for PVC deletion
 	for {
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// Label used by Kueue to select the LocalQueue of a workload
+	kueueQueueNameLabel = "kueue.x-k8s.io/queue-name"
+)
+
+// queueName returns the name of the Queue the AI Job belongs to
+func queueName(aiJob aiv1.Job) string {
+	if aiJob.Spec.QueueName != "" {
+		return aiJob.Spec.QueueName
+	}
+	return aiv1.DefaultQueueName
+}
+
+// jobRejectedError is returned for AI Jobs that can never be admitted as they
+// are, so that they are not requeued until something changes
+type jobRejectedError struct {
+	reason string
+}
+
+func (e *jobRejectedError) Error() string {
+	return e.reason
+}
+
+// isJobRejected reports whether err rejects the AI Job
+func isJobRejected(err error) bool {
+	var rejected *jobRejectedError
+	return errors.As(err, &rejected)
+}
+
+// waitingForAdmission reports whether the AI Job needs capacity from its queue
+// before it can run, either for the first time, when resuming or when it is
+// checked again after being rejected
+func waitingForAdmission(aiJob aiv1.Job) bool {
+	switch aiJob.Status.State {
+	case "", aiv1.JobStateQueued, aiv1.JobStateSuspended, aiv1.JobStateRejected:
+		return !aiJob.Spec.Suspend
+	}
+	return false
+}
+
+// getQueue loads the Queue of the AI Job, returning nil when the job
+// falls back to a default queue that does not exist
+func (r *JobReconciler) getQueue(ctx context.Context, aiJob aiv1.Job) (*aiv1.Queue, error) {
+	queue := &aiv1.Queue{}
+	err := r.Get(ctx, client.ObjectKey{Name: queueName(aiJob), Namespace: aiJob.Namespace}, queue)
+	if err != nil {
+		if apierrors.IsNotFound(err) && aiJob.Spec.QueueName == "" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return queue, nil
+}
+
+// admit checks whether the AI Job can start. Running jobs are never queued
+// again, suspended jobs go through the queue when they resume. Pending jobs are ordered by priority, then by
+// creation time, and admitted in that order while the queue has capacity.
+// It returns the queue position of the job, 0 meaning it is admitted.
+func (r *JobReconciler) admit(ctx context.Context, aiJob aiv1.Job) (int32, error) {
+	logger := log.FromContext(ctx)
+
+	if !waitingForAdmission(aiJob) {
+		return 0, nil
+	}
+
+	queue, err := r.getQueue(ctx, aiJob)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get queue %s: %w", queueName(aiJob), err)
+	}
+
+	// No queue or admission delegated to Kueue
+	if queue == nil || queue.Spec.KueueQueueName != "" {
+		return 0, nil
+	}
+
+	var aiJobs aiv1.JobList
+	if err := r.List(ctx, &aiJobs, client.InNamespace(aiJob.Namespace)); err != nil {
+		return 0, err
+	}
+
+	var activeJobs, usedGPUs int32
+	var pending []aiv1.Job
+	for _, job := range aiJobs.Items {
+		if queueName(job) != queue.Name || !job.DeletionTimestamp.IsZero() {
+			continue
+		}
+		// Rejected jobs do not hold a place in the queue
+		if job.Status.State == aiv1.JobStateRejected && job.Name != aiJob.Name {
+			continue
+		}
+		if waitingForAdmission(job) {
+			pending = append(pending, job)
+		} else if job.Status.State == aiv1.JobStateRunning {
+			activeJobs++
+			usedGPUs += job.Spec.GPUs
+		}
+	}
+
+	slices.SortFunc(pending, func(a, b aiv1.Job) int {
+		if c := cmp.Compare(b.Spec.Priority, a.Spec.Priority); c != 0 {
+			return c
+		}
+		if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	// Walk the pending jobs in order, stopping at the first one that does not fit
+	// so that large jobs are not starved by smaller ones behind them
+	for i, job := range pending {
+		// A job larger than the whole queue would block everything behind it
+		if queue.Spec.MaxGPUs > 0 && job.Spec.GPUs > queue.Spec.MaxGPUs {
+			if job.Name == aiJob.Name {
+				return 0, &jobRejectedError{reason: fmt.Sprintf("job requests %d GPUs but queue %s allows at most %d",
+					job.Spec.GPUs, queue.Name, queue.Spec.MaxGPUs)}
+			}
+			continue
+		}
+		fits := (queue.Spec.MaxConcurrentJobs == 0 || activeJobs < queue.Spec.MaxConcurrentJobs) &&
+			(queue.Spec.MaxGPUs == 0 || usedGPUs+job.Spec.GPUs <= queue.Spec.MaxGPUs)
+		if !fits {
+			position := slices.IndexFunc(pending[i:], func(j aiv1.Job) bool {
+				return j.Name == aiJob.Name
+			})
+			if position < 0 {
+				// The job is not in the cached list yet, put it at the back
+				position = len(pending) - i
+			}
+			logger.Info("AI Job is queued", "queue", queue.Name, "position", position+1)
+			return int32(position + 1), nil
+		}
+		if job.Name == aiJob.Name {
+			return 0, nil
+		}
+		activeJobs++
+		usedGPUs += job.Spec.GPUs
+	}
+
+	return 0, nil
+}
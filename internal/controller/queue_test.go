@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Queues", func() {
+	It("should reconcile the waiting jobs of the namespace when a queue changes", func() {
+		scheme := runtime.NewScheme()
+		Expect(aiv1.AddToScheme(scheme)).To(Succeed())
+
+		newJob := func(name, namespace string, state aiv1.JobState) *aiv1.Job {
+			return &aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Status:     aiv1.JobStatus{State: state},
+			}
+		}
+		r := &JobReconciler{Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			newJob("rejected", "team-a", aiv1.JobStateRejected),
+			newJob("queued", "team-a", aiv1.JobStateQueued),
+			newJob("running", "team-a", aiv1.JobStateRunning),
+			newJob("other", "team-b", aiv1.JobStateRejected),
+		).Build()}
+
+		queue := &aiv1.Queue{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "team-a"}}
+		Expect(r.queuedJobs(context.Background(), queue)).To(ConsistOf(
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "rejected", Namespace: "team-a"}},
+			reconcile.Request{NamespacedName: types.NamespacedName{Name: "queued", Namespace: "team-a"}},
+		))
+	})
+})
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"encoding/json"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Writable directory of the containers, whose root filesystem is read-only
+	scratchVolumeName = "scratch"
+	scratchMountPath  = "/scratch"
+
+	// User and group running the containers, so images need not define one
+	jobRunAsUser = 1000
+)
+
+// podSecurityContext returns the security context of the pods of the AI
+// Job, which meets the restricted Pod Security Standard unless the job
+// overrides some of its fields
+func podSecurityContext(aiJob aiv1.Job) *corev1.PodSecurityContext {
+	// Each field has its own value, as the overrides are written through
+	runAsNonRoot := true
+	user, group, fsGroup := int64(jobRunAsUser), int64(jobRunAsUser), int64(jobRunAsUser)
+	return overlay(&corev1.PodSecurityContext{
+		RunAsNonRoot: &runAsNonRoot,
+		RunAsUser:    &user,
+		RunAsGroup:   &group,
+		// Let the user write to the job volume
+		FSGroup: &fsGroup,
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}, aiJob.Spec.PodSecurityContext)
+}
+
+// containerSecurityContext returns the security context of the containers of
+// the AI Job, which meets the restricted Pod Security Standard unless the job
+// overrides some of its fields
+func containerSecurityContext(aiJob aiv1.Job) *corev1.SecurityContext {
+	runAsNonRoot := true
+	allowPrivilegeEscalation := false
+	readOnlyRootFilesystem := true
+	return overlay(&corev1.SecurityContext{
+		RunAsNonRoot:             &runAsNonRoot,
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		ReadOnlyRootFilesystem:   &readOnlyRootFilesystem,
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}, aiJob.Spec.SecurityContext)
+}
+
+// overlay sets the fields of override over the defaults, field by field down
+// to the nested structs like the capabilities, whose lists are replaced
+func overlay[T any](defaults, override *T) *T {
+	if override == nil {
+		return defaults
+	}
+	// Security contexts only hold plain fields, which always encode
+	data, _ := json.Marshal(override)
+	_ = json.Unmarshal(data, defaults)
+	return defaults
+}
+
+// configureSecurity sets the security contexts of the pod and all its
+// containers, keeps the token of the default service account out of the pod,
+// and gives the containers a scratch directory as home and for temporary files
+func configureSecurity(aiJob aiv1.Job, pod *corev1.PodSpec) {
+	automountServiceAccountToken := false
+	pod.AutomountServiceAccountToken = &automountServiceAccountToken
+	pod.SecurityContext = podSecurityContext(aiJob)
+	pod.Volumes = append(pod.Volumes, corev1.Volume{
+		Name: scratchVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+
+	for _, containers := range [][]corev1.Container{pod.InitContainers, pod.Containers} {
+		for i := range containers {
+			containers[i].SecurityContext = containerSecurityContext(aiJob)
+			containers[i].VolumeMounts = append(containers[i].VolumeMounts, corev1.VolumeMount{
+				Name:      scratchVolumeName,
+				MountPath: scratchMountPath,
+			})
+			containers[i].Env = append(containers[i].Env,
+				corev1.EnvVar{Name: "HOME", Value: scratchMountPath},
+				corev1.EnvVar{Name: "TMPDIR", Value: scratchMountPath},
+			)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Security", func() {
+	newPod := func() *corev1.PodSpec {
+		return &corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "download"}},
+			Containers:     []corev1.Container{{Name: "train"}},
+		}
+	}
+
+	It("should meet the restricted Pod Security Standard by default", func() {
+		pod := newPod()
+		configureSecurity(aiv1.Job{}, pod)
+
+		Expect(*pod.AutomountServiceAccountToken).To(BeFalse())
+		Expect(*pod.SecurityContext.RunAsNonRoot).To(BeTrue())
+		Expect(*pod.SecurityContext.RunAsUser).To(Equal(int64(1000)))
+		Expect(pod.SecurityContext.SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeRuntimeDefault))
+		Expect(pod.Volumes).To(ContainElement(HaveField("Name", "scratch")))
+		for _, container := range append(pod.InitContainers, pod.Containers...) {
+			Expect(*container.SecurityContext.AllowPrivilegeEscalation).To(BeFalse())
+			Expect(*container.SecurityContext.ReadOnlyRootFilesystem).To(BeTrue())
+			Expect(container.SecurityContext.Capabilities.Drop).To(ConsistOf(corev1.Capability("ALL")))
+			Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "scratch", MountPath: "/scratch"}))
+			Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "HOME", Value: "/scratch"}))
+		}
+	})
+
+	It("should override the defaults field by field with the security contexts of the job", func() {
+		group := int64(2000)
+		readOnlyRootFilesystem := false
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{
+			PodSecurityContext: &corev1.PodSecurityContext{RunAsGroup: &group},
+			SecurityContext: &corev1.SecurityContext{
+				ReadOnlyRootFilesystem: &readOnlyRootFilesystem,
+				Capabilities:           &corev1.Capabilities{Add: []corev1.Capability{"SYS_PTRACE"}},
+			},
+		}}
+		pod := newPod()
+		configureSecurity(aiJob, pod)
+
+		Expect(*pod.SecurityContext.RunAsGroup).To(Equal(int64(2000)))
+		Expect(*pod.SecurityContext.RunAsUser).To(Equal(int64(1000)))
+		Expect(*pod.SecurityContext.RunAsNonRoot).To(BeTrue())
+		Expect(pod.SecurityContext.SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeRuntimeDefault))
+
+		container := pod.Containers[0].SecurityContext
+		Expect(*container.ReadOnlyRootFilesystem).To(BeFalse())
+		Expect(*container.AllowPrivilegeEscalation).To(BeFalse())
+		Expect(container.Capabilities.Add).To(ConsistOf(corev1.Capability("SYS_PTRACE")))
+		Expect(container.Capabilities.Drop).To(ConsistOf(corev1.Capability("ALL")))
+		Expect(container.Capabilities).NotTo(BeIdenticalTo(aiJob.Spec.SecurityContext.Capabilities))
+		Expect(*aiJob.Spec.SecurityContext).To(Equal(corev1.SecurityContext{
+			ReadOnlyRootFilesystem: &readOnlyRootFilesystem,
+			Capabilities:           &corev1.Capabilities{Add: []corev1.Capability{"SYS_PTRACE"}},
+		}))
+	})
+})
@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// jobServiceAccountName returns the ServiceAccount the pods of the AI Job run
+// as, empty for the default one of the namespace
+func jobServiceAccountName(aiJob aiv1.Job) string {
+	if aiJob.Spec.ServiceAccount != nil {
+		return aiJob.Name
+	}
+	return aiJob.Spec.ServiceAccountName
+}
+
+// configureServiceAccount runs the pod as the ServiceAccount of the AI Job,
+// mounting its token so the job can reach the API server and exchange it for
+// cloud credentials
+func configureServiceAccount(aiJob aiv1.Job, pod *corev1.PodTemplateSpec) {
+	name := jobServiceAccountName(aiJob)
+	if name == "" {
+		return
+	}
+	automountServiceAccountToken := true
+	pod.Spec.ServiceAccountName = name
+	pod.Spec.AutomountServiceAccountToken = &automountServiceAccountToken
+	if spec := aiJob.Spec.ServiceAccount; spec != nil && len(spec.PodLabels) > 0 {
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		// The labels selecting the pods are kept
+		for key, value := range spec.PodLabels {
+			if _, ok := pod.Labels[key]; !ok {
+				pod.Labels[key] = value
+			}
+		}
+	}
+}
+
+// createServiceAccount creates or updates the ServiceAccount the AI Job asks
+// for, and the Role granting its rules
+func (r *JobReconciler) createServiceAccount(ctx context.Context, aiJob aiv1.Job) error {
+	spec := aiJob.Spec.ServiceAccount
+	if spec == nil {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+	meta := metav1.ObjectMeta{
+		Name:      jobServiceAccountName(aiJob),
+		Namespace: aiJob.Namespace,
+		Labels: map[string]string{
+			"app.kubernetes.io/name": aiJob.Name,
+		},
+	}
+
+	serviceAccount := &corev1.ServiceAccount{}
+	err := r.Get(ctx, client.ObjectKey{Name: meta.Name, Namespace: meta.Namespace}, serviceAccount)
+	switch {
+	case apierrors.IsNotFound(err):
+		serviceAccount = &corev1.ServiceAccount{ObjectMeta: *meta.DeepCopy()}
+		serviceAccount.Annotations = maps.Clone(spec.Annotations)
+		if err := r.setOwnerReference(&aiJob, serviceAccount); err != nil {
+			return fmt.Errorf("failed to set owner reference: %w", err)
+		}
+		if err := r.Create(ctx, serviceAccount); err != nil {
+			logger.Error(err, "unable to create service account")
+			return err
+		}
+	case err != nil:
+		return err
+	case !metav1.IsControlledBy(serviceAccount, &aiJob):
+		return &ownershipConflictError{kind: "ServiceAccount", name: meta.Name}
+	default:
+		// Keep the annotations set by others, like the tokens of old clusters
+		updated := false
+		for key, value := range spec.Annotations {
+			if serviceAccount.Annotations[key] != value {
+				if serviceAccount.Annotations == nil {
+					serviceAccount.Annotations = map[string]string{}
+				}
+				serviceAccount.Annotations[key] = value
+				updated = true
+			}
+		}
+		if updated {
+			if err := r.Update(ctx, serviceAccount); err != nil {
+				logger.Error(err, "unable to update service account")
+				return err
+			}
+		}
+	}
+
+	// Revoke the permissions when the rules are cleared
+	if len(spec.Rules) == 0 {
+		for _, obj := range []client.Object{
+			&rbacv1.RoleBinding{ObjectMeta: *meta.DeepCopy()},
+			&rbacv1.Role{ObjectMeta: *meta.DeepCopy()},
+		} {
+			if err := r.deleteControlled(ctx, aiJob, obj); err != nil {
+				logger.Error(err, "unable to delete role")
+				return err
+			}
+		}
+		return nil
+	}
+
+	role := &rbacv1.Role{}
+	err = r.Get(ctx, client.ObjectKey{Name: meta.Name, Namespace: meta.Namespace}, role)
+	switch {
+	case apierrors.IsNotFound(err):
+		role = &rbacv1.Role{ObjectMeta: *meta.DeepCopy(), Rules: spec.Rules}
+		if err := r.setOwnerReference(&aiJob, role); err != nil {
+			return fmt.Errorf("failed to set owner reference: %w", err)
+		}
+		if err := r.Create(ctx, role); err != nil {
+			logger.Error(err, "unable to create role")
+			return err
+		}
+	case err != nil:
+		return err
+	case !metav1.IsControlledBy(role, &aiJob):
+		return &ownershipConflictError{kind: "Role", name: meta.Name}
+	case !equality.Semantic.DeepEqual(role.Rules, spec.Rules):
+		role.Rules = spec.Rules
+		if err := r.Update(ctx, role); err != nil {
+			logger.Error(err, "unable to update role")
+			return err
+		}
+	}
+
+	roleBinding := &rbacv1.RoleBinding{}
+	err = r.Get(ctx, client.ObjectKey{Name: meta.Name, Namespace: meta.Namespace}, roleBinding)
+	if apierrors.IsNotFound(err) {
+		roleBinding = &rbacv1.RoleBinding{
+			ObjectMeta: *meta.DeepCopy(),
+			Subjects: []rbacv1.Subject{
+				{
+					Kind:      rbacv1.ServiceAccountKind,
+					Name:      meta.Name,
+					Namespace: meta.Namespace,
+				},
+			},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "Role",
+				Name:     meta.Name,
+			},
+		}
+		if err := r.setOwnerReference(&aiJob, roleBinding); err != nil {
+			return fmt.Errorf("failed to set owner reference: %w", err)
+		}
+		if err := r.Create(ctx, roleBinding); err != nil {
+			logger.Error(err, "unable to create role binding")
+			return err
+		}
+		return nil
+	}
+	if err == nil && !metav1.IsControlledBy(roleBinding, &aiJob) {
+		return &ownershipConflictError{kind: "RoleBinding", name: meta.Name}
+	}
+	return err
+}
+
+// deleteServiceAccount deletes the ServiceAccount created for the AI Job, its
+// Role and its binding. Objects of the same name the job does not control are
+// left untouched.
+func (r *JobReconciler) deleteServiceAccount(ctx context.Context, aiJob aiv1.Job) error {
+	if aiJob.Spec.ServiceAccount == nil {
+		return nil
+	}
+	meta := metav1.ObjectMeta{Name: jobServiceAccountName(aiJob), Namespace: aiJob.Namespace}
+	for _, obj := range []client.Object{
+		&rbacv1.RoleBinding{ObjectMeta: *meta.DeepCopy()},
+		&rbacv1.Role{ObjectMeta: *meta.DeepCopy()},
+		&corev1.ServiceAccount{ObjectMeta: *meta.DeepCopy()},
+	} {
+		if err := r.deleteControlled(ctx, aiJob, obj); err != nil {
+			log.FromContext(ctx).Error(err, "unable to delete service account resources")
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteControlled deletes the object when the AI Job controls it. The UID
+// precondition keeps an object recreated by others in the meantime.
+func (r *JobReconciler) deleteControlled(ctx context.Context, aiJob aiv1.Job, obj client.Object) error {
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(obj, &aiJob) {
+		return nil
+	}
+	uid := obj.GetUID()
+	return client.IgnoreNotFound(r.Delete(ctx, obj, client.Preconditions{UID: &uid}))
+}
+
+// ownershipConflictError is returned when an object the AI Job would create
+// already exists and is not controlled by the job
+type ownershipConflictError struct {
+	kind, name string
+}
+
+func (e *ownershipConflictError) Error() string {
+	return fmt.Sprintf("%s %s already exists and is not controlled by the job", e.kind, e.name)
+}
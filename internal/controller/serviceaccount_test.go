@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Service accounts", func() {
+	newPod := func() *corev1.PodTemplateSpec {
+		pod := &corev1.PodTemplateSpec{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app.kubernetes.io/name": "job"}},
+		}
+		configureSecurity(aiv1.Job{}, &pod.Spec)
+		return pod
+	}
+
+	It("should run as the default account without token", func() {
+		pod := newPod()
+		configureServiceAccount(aiv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "job"}}, pod)
+		Expect(pod.Spec.ServiceAccountName).To(BeEmpty())
+		Expect(*pod.Spec.AutomountServiceAccountToken).To(BeFalse())
+	})
+
+	It("should run as an existing account", func() {
+		pod := newPod()
+		configureServiceAccount(aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "job"},
+			Spec:       aiv1.JobSpec{ServiceAccountName: "trainer"},
+		}, pod)
+		Expect(pod.Spec.ServiceAccountName).To(Equal("trainer"))
+		Expect(*pod.Spec.AutomountServiceAccountToken).To(BeTrue())
+	})
+
+	It("should run as the account created for the job with its pod labels", func() {
+		pod := newPod()
+		configureServiceAccount(aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "job"},
+			Spec: aiv1.JobSpec{ServiceAccount: &aiv1.JobServiceAccount{
+				Annotations: map[string]string{"azure.workload.identity/client-id": "0000"},
+				PodLabels: map[string]string{
+					"azure.workload.identity/use": "true",
+					"app.kubernetes.io/name":      "other",
+				},
+			}},
+		}, pod)
+		Expect(pod.Spec.ServiceAccountName).To(Equal("job"))
+		Expect(pod.Labels).To(Equal(map[string]string{
+			"app.kubernetes.io/name":      "job",
+			"azure.workload.identity/use": "true",
+		}))
+	})
+
+	Context("Ownership", func() {
+		ctx := context.Background()
+		var (
+			r     *JobReconciler
+			aiJob aiv1.Job
+		)
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(aiv1.AddToScheme(scheme)).To(Succeed())
+			aiJob = aiv1.Job{
+				ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "default", UID: "job-uid"},
+				Spec: aiv1.JobSpec{ServiceAccount: &aiv1.JobServiceAccount{
+					Rules: []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}}},
+				}},
+			}
+			r = &JobReconciler{
+				Client: fake.NewClientBuilder().WithScheme(scheme).Build(),
+				Scheme: scheme,
+			}
+		})
+
+		It("should create and delete the objects of the job", func() {
+			Expect(r.createServiceAccount(ctx, aiJob)).To(Succeed())
+			key := client.ObjectKey{Name: "job", Namespace: "default"}
+			for _, obj := range []client.Object{&corev1.ServiceAccount{}, &rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+				Expect(r.Get(ctx, key, obj)).To(Succeed())
+				Expect(metav1.IsControlledBy(obj, &aiJob)).To(BeTrue())
+			}
+
+			Expect(r.deleteServiceAccount(ctx, aiJob)).To(Succeed())
+			for _, obj := range []client.Object{&corev1.ServiceAccount{}, &rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+				Expect(apierrors.IsNotFound(r.Get(ctx, key, obj))).To(BeTrue())
+			}
+		})
+
+		It("should delete the role when the rules are cleared", func() {
+			Expect(r.createServiceAccount(ctx, aiJob)).To(Succeed())
+
+			aiJob.Spec.ServiceAccount.Rules = nil
+			Expect(r.createServiceAccount(ctx, aiJob)).To(Succeed())
+			key := client.ObjectKey{Name: "job", Namespace: "default"}
+			Expect(r.Get(ctx, key, &corev1.ServiceAccount{})).To(Succeed())
+			for _, obj := range []client.Object{&rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+				Expect(apierrors.IsNotFound(r.Get(ctx, key, obj))).To(BeTrue())
+			}
+		})
+
+		It("should neither adopt nor delete the objects of others", func() {
+			meta := metav1.ObjectMeta{Name: "job", Namespace: "default"}
+			existing := []client.Object{
+				&corev1.ServiceAccount{ObjectMeta: *meta.DeepCopy()},
+				&rbacv1.Role{ObjectMeta: *meta.DeepCopy()},
+				&rbacv1.RoleBinding{ObjectMeta: *meta.DeepCopy()},
+			}
+			for _, obj := range existing {
+				Expect(r.Create(ctx, obj)).To(Succeed())
+			}
+
+			err := r.createServiceAccount(ctx, aiJob)
+			var conflict *ownershipConflictError
+			Expect(err).To(BeAssignableToTypeOf(conflict))
+			Expect(err).To(MatchError("ServiceAccount job already exists and is not controlled by the job"))
+
+			role := &rbacv1.Role{}
+			Expect(r.Get(ctx, client.ObjectKeyFromObject(existing[1]), role)).To(Succeed())
+			Expect(role.Rules).To(BeEmpty())
+
+			Expect(r.deleteServiceAccount(ctx, aiJob)).To(Succeed())
+			for _, obj := range existing {
+				Expect(r.Get(ctx, client.ObjectKeyFromObject(obj), obj)).To(Succeed())
+			}
+		})
+	})
+})
@@ -0,0 +1,245 @@
+package controller
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/backend"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Volume and mount path of the PVC holding the model
+	sourceVolumeName = "model-source"
+	sourceMountPath  = "/source"
+
+	// API group of the VolumeSnapshots restored into the job volume
+	volumeSnapshotAPIGroup = "snapshot.storage.k8s.io"
+)
+
+// downloadWrapper runs the download command of the model source, then
+// verifies the model files against the checksum file when one is set
+const downloadWrapper = `set -e
+"$@"
+if [ -n "$CHECKSUM_FILE" ]; then
+  cd "$MODEL_DIR"
+  sha256sum -c "$CHECKSUM_FILE"
+fi
+`
+
+// copyScript copies the directory given as first argument to MODEL_DIR
+const copyScript = `mkdir -p "$MODEL_DIR"
+cp -a "$1"/. "$MODEL_DIR"
+`
+
+// httpScript downloads the archive next to MODEL_DIR, verifies its checksum
+// and extracts it
+const httpScript = `archive="$MODEL_DIR.tar"
+curl -fsSL --retry 5 -o "$archive" "$MODEL_URL"
+echo "$MODEL_SHA256  $archive" | sha256sum -c
+mkdir -p "$MODEL_DIR"
+tar -xf "$archive" -C "$MODEL_DIR"
+rm "$archive"
+`
+
+// snapshotScript checks that the restored snapshot holds the model
+const snapshotScript = `if [ ! -d "$MODEL_DIR" ]; then
+  echo "the snapshot has no $MODEL_DIR directory" >&2
+  exit 1
+fi
+`
+
+// modelDownload is what a model source adds to the init container
+type modelDownload struct {
+	command      []string
+	env          []corev1.EnvVar
+	envFrom      []corev1.EnvFromSource
+	volumes      []corev1.Volume
+	volumeMounts []corev1.VolumeMount
+}
+
+// downloadStrategy writes the model of an AI Job to modelDir on the job volume
+type downloadStrategy interface {
+	download(aiJob aiv1.Job, trainingBackend backend.Backend, modelDir string) modelDownload
+}
+
+// Download strategies of the model sources
+var downloadStrategies = map[aiv1.ModelSourceType]downloadStrategy{
+	aiv1.ModelSourceHuggingFace:    huggingFaceDownload{},
+	aiv1.ModelSourceObjectStorage:  objectStorageDownload{},
+	aiv1.ModelSourceHTTP:           httpDownload{},
+	aiv1.ModelSourcePVC:            pvcDownload{},
+	aiv1.ModelSourceVolumeSnapshot: volumeSnapshotDownload{},
+	aiv1.ModelSourceOCI:            ociDownload{},
+}
+
+// downloadContainer builds the init container writing the model to modelDir,
+// and the volumes it needs besides the job volume
+func downloadContainer(aiJob aiv1.Job, trainingBackend backend.Backend, modelDir string, volumeMounts []corev1.VolumeMount) (corev1.Container, []corev1.Volume, error) {
+	source := aiJob.Spec.ModelSource
+	strategy, ok := downloadStrategies[source.Type]
+	if !ok {
+		return corev1.Container{}, nil, fmt.Errorf("unknown model source %q", source.Type)
+	}
+	download := strategy.download(aiJob, trainingBackend, modelDir)
+
+	image := source.Image
+	if source.Type == aiv1.ModelSourceOCI {
+		image = source.OCI.Image
+	}
+
+	container := corev1.Container{
+		Name:    fmt.Sprintf("%s-init", aiJob.Name),
+		Image:   image,
+		Command: append([]string{"sh", "-c", downloadWrapper, "sh"}, download.command...),
+		Env: append([]corev1.EnvVar{
+			{Name: "MODEL_DIR", Value: modelDir},
+			{Name: "CHECKSUM_FILE", Value: source.ChecksumFile},
+			{Name: "PYTHONUNBUFFERED", Value: "1"},
+		}, download.env...),
+		EnvFrom:      download.envFrom,
+		VolumeMounts: slices.Concat(volumeMounts, download.volumeMounts),
+	}
+	return container, download.volumes, nil
+}
+
+// huggingFaceDownload downloads the model with the tools of the framework
+type huggingFaceDownload struct{}
+
+func (huggingFaceDownload) download(aiJob aiv1.Job, trainingBackend backend.Backend, modelDir string) modelDownload {
+	return modelDownload{
+		command: trainingBackend.DownloadCommand(aiJob, modelDir),
+		env:     huggingFaceEnv(),
+	}
+}
+
+// objectStorageDownload copies the model with the AWS command line, which
+// verifies the checksums of the objects
+type objectStorageDownload struct{}
+
+func (objectStorageDownload) download(aiJob aiv1.Job, _ backend.Backend, modelDir string) modelDownload {
+	spec := aiJob.Spec.ModelSource.ObjectStorage
+
+	// Google Cloud Storage is read through its S3 interoperability endpoint
+	uri := spec.URI
+	if rest, found := strings.CutPrefix(uri, "gs://"); found {
+		uri = "s3://" + rest
+	}
+
+	download := modelDownload{
+		command: []string{"aws", "s3", "cp", "--recursive", "--no-progress", uri, modelDir},
+	}
+	if spec.Endpoint != "" {
+		download.command = append(download.command, "--endpoint-url", spec.Endpoint)
+	}
+	if spec.CredentialsSecret != "" {
+		download.envFrom = []corev1.EnvFromSource{
+			{
+				SecretRef: &corev1.SecretEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: spec.CredentialsSecret},
+				},
+			},
+		}
+	}
+	return download
+}
+
+// httpDownload downloads and extracts a tarball once its checksum matches
+type httpDownload struct{}
+
+func (httpDownload) download(aiJob aiv1.Job, _ backend.Backend, _ string) modelDownload {
+	spec := aiJob.Spec.ModelSource.HTTP
+	return modelDownload{
+		command: []string{"sh", "-c", httpScript},
+		env: []corev1.EnvVar{
+			{Name: "MODEL_URL", Value: spec.URL},
+			{Name: "MODEL_SHA256", Value: strings.ToLower(spec.SHA256)},
+		},
+	}
+}
+
+// pvcDownload copies the model from a volume mounted read-only
+type pvcDownload struct{}
+
+func (pvcDownload) download(aiJob aiv1.Job, _ backend.Backend, _ string) modelDownload {
+	spec := aiJob.Spec.ModelSource.PVC
+	return modelDownload{
+		command: []string{"sh", "-c", copyScript, "sh", path.Join(sourceMountPath, spec.Path)},
+		volumes: []corev1.Volume{
+			{
+				Name: sourceVolumeName,
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: spec.ClaimName,
+						ReadOnly:  true,
+					},
+				},
+			},
+		},
+		volumeMounts: []corev1.VolumeMount{
+			{
+				Name:      sourceVolumeName,
+				MountPath: sourceMountPath,
+				ReadOnly:  true,
+			},
+		},
+	}
+}
+
+// volumeSnapshotDownload finds the model on the job volume, which is
+// restored from the snapshot when it is created
+type volumeSnapshotDownload struct{}
+
+func (volumeSnapshotDownload) download(aiv1.Job, backend.Backend, string) modelDownload {
+	return modelDownload{
+		command: []string{"sh", "-c", snapshotScript},
+	}
+}
+
+// ociDownload copies the model out of the image the init container runs
+type ociDownload struct{}
+
+func (ociDownload) download(aiJob aiv1.Job, _ backend.Backend, _ string) modelDownload {
+	return modelDownload{
+		command: []string{"sh", "-c", copyScript, "sh", aiJob.Spec.ModelSource.OCI.Path},
+	}
+}
+
+// huggingFaceEnv returns the token giving the training and its download
+// access to the Hugging Face Hub
+func huggingFaceEnv() []corev1.EnvVar {
+	optional := true
+	return []corev1.EnvVar{
+		{
+			Name: "HF_TOKEN",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: huggingFaceSecretName,
+					},
+					Key: "token",
+					// Jobs reading their model from other sources may have no token
+					Optional: &optional,
+				},
+			},
+		},
+	}
+}
+
+// volumeSnapshotDataSource returns the snapshot the job volume is restored
+// from, if any
+func volumeSnapshotDataSource(aiJob aiv1.Job) *corev1.TypedLocalObjectReference {
+	source := aiJob.Spec.ModelSource
+	if source == nil || source.Type != aiv1.ModelSourceVolumeSnapshot {
+		return nil
+	}
+	apiGroup := volumeSnapshotAPIGroup
+	return &corev1.TypedLocalObjectReference{
+		APIGroup: &apiGroup,
+		Kind:     "VolumeSnapshot",
+		Name:     source.VolumeSnapshot.Name,
+	}
+}
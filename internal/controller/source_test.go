@@ -0,0 +1,117 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/backend"
+)
+
+var _ = Describe("Model source", func() {
+	download := func(source *aiv1.ModelSource) (corev1.Container, []corev1.Volume) {
+		aiJob := aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "finetune", Namespace: "default"},
+			Spec:       aiv1.JobSpec{HuggingFaceSecret: "token", ModelSource: source},
+		}
+		Expect(aiJob.Spec.Validate()).To(Succeed())
+
+		trainingBackend, err := backend.Get(aiJob.Spec.Framework)
+		Expect(err).NotTo(HaveOccurred())
+		container, volumes, err := downloadContainer(aiJob, trainingBackend, jobModelDir(aiJob), nil)
+		Expect(err).NotTo(HaveOccurred())
+		return container, volumes
+	}
+
+	It("should download from a Hugging Face mirror", func() {
+		container, volumes := download(&aiv1.ModelSource{
+			Type:         aiv1.ModelSourceHuggingFace,
+			HuggingFace:  &aiv1.HuggingFaceSource{Endpoint: "https://hf-mirror.example.com"},
+			ChecksumFile: "SHA256SUMS",
+		})
+
+		Expect(container.Image).To(Equal("silentehrec/torchtune:latest"))
+		Expect(container.Command[4:]).To(Equal([]string{"tune", "download", "Qwen/Qwen2.5-0.5B-Instruct", "--output-dir", "/workspace/model"}))
+		Expect(container.Env).To(ContainElement(corev1.EnvVar{Name: "CHECKSUM_FILE", Value: "SHA256SUMS"}))
+		Expect(volumes).To(BeEmpty())
+	})
+
+	It("should copy from Google Cloud Storage through its S3 endpoint", func() {
+		container, _ := download(&aiv1.ModelSource{
+			Type: aiv1.ModelSourceObjectStorage,
+			ObjectStorage: &aiv1.ObjectStorageSource{
+				URI:               "gs://models/qwen",
+				CredentialsSecret: "gcs-hmac",
+			},
+		})
+
+		Expect(container.Image).To(Equal("amazon/aws-cli:2.22.35"))
+		Expect(container.Command[4:]).To(Equal([]string{
+			"aws", "s3", "cp", "--recursive", "--no-progress", "s3://models/qwen", "/workspace/model",
+			"--endpoint-url", "https://storage.googleapis.com",
+		}))
+		Expect(container.EnvFrom).To(HaveLen(1))
+		Expect(container.EnvFrom[0].SecretRef.Name).To(Equal("gcs-hmac"))
+	})
+
+	It("should verify the checksum of tarballs", func() {
+		source := &aiv1.ModelSource{
+			Type: aiv1.ModelSourceHTTP,
+			HTTP: &aiv1.HTTPSource{URL: "https://models.example.com/qwen.tar.gz", SHA256: "not-a-checksum"},
+		}
+		Expect((&aiv1.JobSpec{ModelSource: source}).Validate()).To(MatchError(ContainSubstring("SHA-256")))
+
+		source.HTTP.SHA256 = "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855"
+		container, _ := download(source)
+		Expect(container.Env).To(ContainElement(corev1.EnvVar{
+			Name:  "MODEL_SHA256",
+			Value: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		}))
+	})
+
+	It("should copy from an existing volume", func() {
+		container, volumes := download(&aiv1.ModelSource{
+			Type: aiv1.ModelSourcePVC,
+			PVC:  &aiv1.PVCSource{ClaimName: "models", Path: "qwen"},
+		})
+
+		Expect(container.Command[len(container.Command)-1]).To(Equal("/source/qwen"))
+		Expect(container.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "model-source", MountPath: "/source", ReadOnly: true}))
+		Expect(volumes).To(HaveLen(1))
+		Expect(volumes[0].PersistentVolumeClaim.ClaimName).To(Equal("models"))
+	})
+
+	It("should restore the job volume from a snapshot", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{ModelSource: &aiv1.ModelSource{
+			Type:           aiv1.ModelSourceVolumeSnapshot,
+			VolumeSnapshot: &aiv1.VolumeSnapshotSource{Name: "qwen-snapshot"},
+		}}}
+		Expect(aiJob.Spec.Validate()).To(Succeed())
+
+		Expect(jobModelDir(aiJob)).To(Equal("/workspace/model"))
+		dataSource := volumeSnapshotDataSource(aiJob)
+		Expect(dataSource).NotTo(BeNil())
+		Expect(*dataSource.APIGroup).To(Equal("snapshot.storage.k8s.io"))
+		Expect(dataSource.Name).To(Equal("qwen-snapshot"))
+	})
+
+	It("should copy from an OCI image", func() {
+		container, _ := download(&aiv1.ModelSource{
+			Type: aiv1.ModelSourceOCI,
+			OCI:  &aiv1.OCISource{Image: "registry.example.com/models/qwen@sha256:abc"},
+		})
+
+		Expect(container.Image).To(Equal("registry.example.com/models/qwen@sha256:abc"))
+		Expect(container.Command[len(container.Command)-1]).To(Equal("/models"))
+	})
+
+	It("should only require a token for the Hugging Face Hub", func() {
+		Expect((&aiv1.JobSpec{}).Validate()).To(MatchError("HuggingFaceSecret is required"))
+		Expect((&aiv1.JobSpec{ModelSource: &aiv1.ModelSource{
+			Type: aiv1.ModelSourcePVC,
+			PVC:  &aiv1.PVCSource{ClaimName: "models", Path: "../other"},
+		}}).Validate()).To(MatchError(ContainSubstring("relative")))
+	})
+})
@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"context"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/template"
+)
+
+// applyTemplate merges the AI Job spec over the spec of its template, and
+// records the template in the status. Templates are not read again once the
+// merged spec has been recorded, so later changes only affect new jobs: the
+// spec of the job is merged over the recorded one instead. The merged spec
+// is kept in memory, the spec of the job itself is never rewritten.
+func (r *JobReconciler) applyTemplate(ctx context.Context, aiJob *aiv1.Job) error {
+	if aiJob.Spec.TemplateRef == nil {
+		return nil
+	}
+	if effective := aiJob.Status.EffectiveSpec; effective != nil {
+		spec, err := template.Merge(*effective, aiJob.Spec)
+		if err != nil {
+			return err
+		}
+		aiJob.Spec = spec
+		return nil
+	}
+	spec, status, err := template.Resolve(ctx, r.Client, *aiJob)
+	if err != nil {
+		return err
+	}
+	aiJob.Spec = spec
+	aiJob.Status.Template = status
+	return nil
+}
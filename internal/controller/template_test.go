@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Templates", func() {
+	ctx := context.Background()
+
+	It("should record the merged spec in the status without rewriting the job", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(aiv1.AddToScheme(scheme)).To(Succeed())
+
+		jobTemplate := &aiv1.JobTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "gpu", Namespace: "default"},
+			Spec:       aiv1.JobTemplateSpec{Template: aiv1.JobSpec{GPUs: 2, DiskSize: 100}},
+		}
+		aiJob := &aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "finetune", Namespace: "default"},
+			Spec: aiv1.JobSpec{
+				TemplateRef:       &aiv1.TemplateReference{Name: "gpu"},
+				HuggingFaceSecret: "hf-token",
+				DiskSize:          50,
+			},
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "hf-token", Namespace: "default"},
+			Data:       map[string][]byte{"token": []byte("hf_abc")},
+		}
+		r := &JobReconciler{
+			Client: fake.NewClientBuilder().WithScheme(scheme).
+				WithObjects(jobTemplate, aiJob, secret).
+				WithStatusSubresource(&aiv1.Job{}).
+				Build(),
+			Scheme: scheme,
+		}
+
+		By("adding the finalizer and then starting the job")
+		request := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(aiJob)}
+		for range 2 {
+			_, err := r.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(r.Get(ctx, request.NamespacedName, aiJob)).To(Succeed())
+		Expect(aiJob.Finalizers).To(ContainElement(jobFinalizerName))
+		Expect(aiJob.Spec.GPUs).To(BeZero())
+		Expect(aiJob.Spec.Framework).To(BeEmpty())
+		Expect(aiJob.Status.EffectiveSpec).NotTo(BeNil())
+		Expect(aiJob.Status.EffectiveSpec.GPUs).To(Equal(int32(2)))
+		Expect(aiJob.Status.EffectiveSpec.DiskSize).To(Equal(int32(50)))
+
+		By("applying later changes of the job over the recorded spec")
+		jobTemplate.Spec.Template.GPUs = 8
+		Expect(r.Update(ctx, jobTemplate)).To(Succeed())
+		aiJob.Spec.Suspend = true
+		Expect(r.applyTemplate(ctx, aiJob)).To(Succeed())
+		Expect(aiJob.Spec.GPUs).To(Equal(int32(2)))
+		Expect(aiJob.Spec.Suspend).To(BeTrue())
+	})
+})
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"time"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// Port served by TensorBoard
+	tensorBoardPort = 6006
+
+	// Where the viewer mounts the job volume
+	tensorBoardMountPath = "/logs"
+)
+
+// syncTensorBoard runs the TensorBoard viewer of the AI Job until its TTL after
+// completion has passed, and records its URL in the status. Returns how long
+// to wait before the viewer expires.
+func (r *JobReconciler) syncTensorBoard(ctx context.Context, aiJob *aiv1.Job) (time.Duration, error) {
+	spec := aiJob.Spec.TensorBoard
+	if spec == nil || !spec.Enabled {
+		return 0, r.deleteTensorBoard(ctx, aiJob)
+	}
+
+	var remaining time.Duration
+	if aiJob.Status.CompletionTime != nil {
+		ttl := time.Duration(*spec.TTLSecondsAfterFinished) * time.Second
+		remaining = time.Until(aiJob.Status.CompletionTime.Add(ttl))
+		if remaining <= 0 {
+			return 0, r.deleteTensorBoard(ctx, aiJob)
+		}
+	}
+
+	if err := r.createTensorBoard(ctx, *aiJob); err != nil {
+		return 0, err
+	}
+	aiJob.Status.TensorBoardURL = fmt.Sprintf("http://%s.%s.svc:%d", tensorBoardName(*aiJob), aiJob.Namespace, tensorBoardPort)
+	return remaining, nil
+}
+
+// createTensorBoard creates the Deployment and Service of the viewer when they are missing
+func (r *JobReconciler) createTensorBoard(ctx context.Context, aiJob aiv1.Job) error {
+	logger := log.FromContext(ctx)
+
+	name := tensorBoardName(aiJob)
+	labels := map[string]string{
+		"app.kubernetes.io/name":      name,
+		"app.kubernetes.io/component": "tensorboard",
+		"app.kubernetes.io/part-of":   aiJob.Name,
+	}
+
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: aiJob.Namespace}, deployment)
+	if apierrors.IsNotFound(err) {
+		deployment = tensorBoardDeployment(aiJob, name, labels)
+		configureSecurity(aiJob, &deployment.Spec.Template.Spec)
+		if err := r.setOwnerReference(&aiJob, deployment); err != nil {
+			return fmt.Errorf("failed to set owner reference: %w", err)
+		}
+		logger.Info("creating TensorBoard viewer")
+		if err := r.Create(ctx, deployment); err != nil {
+			logger.Error(err, "unable to create TensorBoard deployment")
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	service := &corev1.Service{}
+	err = r.Get(ctx, client.ObjectKey{Name: name, Namespace: aiJob.Namespace}, service)
+	if apierrors.IsNotFound(err) {
+		service = &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: aiJob.Namespace,
+				Labels:    labels,
+			},
+			Spec: corev1.ServiceSpec{
+				Selector: labels,
+				Ports: []corev1.ServicePort{
+					{
+						Name:       "http",
+						Port:       tensorBoardPort,
+						TargetPort: intstr.FromInt32(tensorBoardPort),
+					},
+				},
+			},
+		}
+		if err := r.setOwnerReference(&aiJob, service); err != nil {
+			return fmt.Errorf("failed to set owner reference: %w", err)
+		}
+		if err := r.Create(ctx, service); err != nil {
+			logger.Error(err, "unable to create TensorBoard service")
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// tensorBoardDeployment builds the viewer, which mounts the job volume read-only
+func tensorBoardDeployment(aiJob aiv1.Job, name string, labels map[string]string) *appsv1.Deployment {
+	replicas := int32(1)
+
+	// Serve the directory of the TensorBoard metric logger, or the whole volume
+	logDir := tensorBoardMountPath
+	if tracking := aiJob.Spec.Tracking; tracking != nil && tracking.Provider == aiv1.TrackingProviderTensorBoard {
+		logDir = path.Join(tensorBoardMountPath, tracking.TensorBoard.LogDir)
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: aiJob.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			// Never run two viewers on a volume that may be single-node
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "tensorboard",
+							Image: aiJob.Spec.TensorBoard.Image,
+							Command: []string{
+								"tensorboard",
+								"--logdir", logDir,
+								"--bind_all",
+								"--port", fmt.Sprint(tensorBoardPort),
+							},
+							Ports: []corev1.ContainerPort{
+								{
+									Name:          "http",
+									ContainerPort: tensorBoardPort,
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      jobDefaultVolumeName,
+									MountPath: tensorBoardMountPath,
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: jobDefaultVolumeName,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: aiJob.Name,
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// Volumes that cannot be attached to several nodes are shared with the
+	// training pod by running on its node. Finished pods are not considered
+	// by the scheduler, so the affinity is only a preference.
+	if !slices.Contains(aiJob.Spec.AccessModes, corev1.ReadWriteMany) &&
+		!slices.Contains(aiJob.Spec.AccessModes, corev1.ReadOnlyMany) {
+		deployment.Spec.Template.Spec.Affinity = &corev1.Affinity{
+			PodAffinity: &corev1.PodAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+					{
+						Weight: 100,
+						PodAffinityTerm: corev1.PodAffinityTerm{
+							LabelSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{batchv1.JobNameLabel: aiJob.Name},
+							},
+							TopologyKey: corev1.LabelHostname,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	return deployment
+}
+
+// deleteTensorBoard removes the viewer of the AI Job, if it runs
+func (r *JobReconciler) deleteTensorBoard(ctx context.Context, aiJob *aiv1.Job) error {
+	if aiJob.Status.TensorBoardURL == "" {
+		return nil
+	}
+
+	meta := metav1.ObjectMeta{Name: tensorBoardName(*aiJob), Namespace: aiJob.Namespace}
+	for _, obj := range []client.Object{&appsv1.Deployment{ObjectMeta: meta}, &corev1.Service{ObjectMeta: meta}} {
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	log.FromContext(ctx).Info("removed TensorBoard viewer")
+	aiJob.Status.TensorBoardURL = ""
+	return nil
+}
+
+// tensorBoardName returns the name of the viewer Deployment and Service
+func tensorBoardName(aiJob aiv1.Job) string {
+	return aiJob.Name + "-tensorboard"
+}
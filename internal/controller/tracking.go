@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/tracking"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	// Keys of the credentials secret read by the operator to create MLflow runs
+	mlflowTokenKey    = "MLFLOW_TRACKING_TOKEN"
+	mlflowUsernameKey = "MLFLOW_TRACKING_USERNAME"
+	mlflowPasswordKey = "MLFLOW_TRACKING_PASSWORD"
+
+	// Weights & Biases server used when no base URL is set
+	wandbDefaultBaseURL = "https://wandb.ai"
+
+	// Tag of the tracking runs identifying their AI Job
+	trackingUIDTag = "ai.re-cinq.com/uid"
+)
+
+// startTracking creates the run of the AI Job in its experiment tracker and
+// records it in the status. Runs are created once, before the batch job: an
+// MLflow run tagged with the UID of the job is reused, should the status
+// update recording it have failed.
+func (r *JobReconciler) startTracking(ctx context.Context, aiJob *aiv1.Job) error {
+	spec := aiJob.Spec.Tracking
+	if spec == nil || aiJob.Status.Tracking != nil {
+		return nil
+	}
+
+	switch spec.Provider {
+	case aiv1.TrackingProviderMLflow:
+		credentials, err := r.trackingCredentials(ctx, *aiJob)
+		if err != nil {
+			return err
+		}
+		mlflow := &tracking.MLflowClient{
+			TrackingURI: spec.MLflow.TrackingURI,
+			Token:       credentials[mlflowTokenKey],
+			Username:    credentials[mlflowUsernameKey],
+			Password:    credentials[mlflowPasswordKey],
+		}
+		run, err := mlflow.CreateRun(ctx, mlflowExperimentName(*aiJob), aiJob.Name, trackingTags(*aiJob), trackingUIDTag)
+		if err != nil {
+			return fmt.Errorf("failed to create MLflow run: %w", err)
+		}
+		aiJob.Status.Tracking = &aiv1.JobTrackingStatus{RunID: run.ID, RunURL: run.URL}
+	case aiv1.TrackingProviderWandB:
+		// The run is created by the training, under the ID chosen here
+		runID := string(aiJob.UID)
+		status := &aiv1.JobTrackingStatus{RunID: runID}
+		if wandb := spec.WandB; wandb.Entity != "" {
+			baseURL := wandb.BaseURL
+			if baseURL == "" {
+				baseURL = wandbDefaultBaseURL
+			}
+			status.RunURL = fmt.Sprintf("%s/%s/%s/runs/%s", strings.TrimSuffix(baseURL, "/"), wandb.Entity, wandb.Project, runID)
+		}
+		aiJob.Status.Tracking = status
+	default:
+		// TensorBoard event files are written to the volume
+		return nil
+	}
+
+	log.FromContext(ctx).Info("tracking run created", "provider", spec.Provider, "run", aiJob.Status.Tracking.RunID)
+	return nil
+}
+
+// trackingCredentials returns the content of the credentials secret, if any
+func (r *JobReconciler) trackingCredentials(ctx context.Context, aiJob aiv1.Job) (map[string]string, error) {
+	name := aiJob.Spec.Tracking.CredentialsSecret
+	if name == "" {
+		return nil, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: aiJob.Namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get tracking credentials: %w", err)
+	}
+
+	credentials := make(map[string]string, len(secret.Data))
+	for key, value := range secret.Data {
+		credentials[key] = string(value)
+	}
+	return credentials, nil
+}
+
+// mlflowExperimentName returns the MLflow experiment of the AI Job
+func mlflowExperimentName(aiJob aiv1.Job) string {
+	if name := aiJob.Spec.Tracking.MLflow.ExperimentName; name != "" {
+		return name
+	}
+	return aiJob.Namespace
+}
+
+// trackingTags identifies the AI Job in its tracking run
+func trackingTags(aiJob aiv1.Job) map[string]string {
+	return map[string]string{
+		"ai.re-cinq.com/job":       aiJob.Name,
+		"ai.re-cinq.com/namespace": aiJob.Namespace,
+		trackingUIDTag:             string(aiJob.UID),
+		"ai.re-cinq.com/model":     aiJob.Spec.Model,
+		"ai.re-cinq.com/image":     aiJob.Spec.Image,
+	}
+}
+
+// jobTensorBoardDir returns where the training writes TensorBoard event files
+func jobTensorBoardDir(aiJob aiv1.Job) string {
+	tracking := aiJob.Spec.Tracking
+	if tracking == nil || tracking.Provider != aiv1.TrackingProviderTensorBoard {
+		return ""
+	}
+	return workspacePath(aiJob, tracking.TensorBoard.LogDir)
+}
+
+// configureTracking points the training container at the experiment tracker
+// of the AI Job. The framework backend selects the matching logger.
+func configureTracking(aiJob aiv1.Job, container *corev1.Container) {
+	spec := aiJob.Spec.Tracking
+	if spec == nil {
+		return
+	}
+
+	if spec.CredentialsSecret != "" {
+		container.EnvFrom = append(container.EnvFrom, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: spec.CredentialsSecret},
+			},
+		})
+	}
+
+	var runID string
+	if aiJob.Status.Tracking != nil {
+		runID = aiJob.Status.Tracking.RunID
+	}
+
+	switch spec.Provider {
+	case aiv1.TrackingProviderMLflow:
+		container.Env = append(container.Env,
+			corev1.EnvVar{Name: "MLFLOW_TRACKING_URI", Value: spec.MLflow.TrackingURI},
+			corev1.EnvVar{Name: "MLFLOW_EXPERIMENT_NAME", Value: mlflowExperimentName(aiJob)},
+			corev1.EnvVar{Name: "MLFLOW_RUN_ID", Value: runID},
+		)
+	case aiv1.TrackingProviderWandB:
+		var tags []string
+		for key, value := range trackingTags(aiJob) {
+			tags = append(tags, strings.TrimPrefix(key, "ai.re-cinq.com/")+":"+value)
+		}
+		slices.Sort(tags)
+		container.Env = append(container.Env,
+			corev1.EnvVar{Name: "WANDB_RUN_ID", Value: runID},
+			corev1.EnvVar{Name: "WANDB_RESUME", Value: "allow"},
+			corev1.EnvVar{Name: "WANDB_TAGS", Value: strings.Join(tags, ",")},
+			corev1.EnvVar{Name: "WANDB_PROJECT", Value: spec.WandB.Project},
+		)
+		if spec.WandB.Entity != "" {
+			container.Env = append(container.Env, corev1.EnvVar{Name: "WANDB_ENTITY", Value: spec.WandB.Entity})
+		}
+		if spec.WandB.BaseURL != "" {
+			container.Env = append(container.Env, corev1.EnvVar{Name: "WANDB_BASE_URL", Value: spec.WandB.BaseURL})
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// In-memory volume replacing the small /dev/shm of the container runtime
+	sharedMemoryVolumeName = "shm"
+	sharedMemoryMountPath  = "/dev/shm"
+)
+
+// configureVolumes sizes the shared memory and scratch directory of the pod
+// and mounts the extra volumes of the AI Job in the given containers, which
+// are the training or postprocess ones
+func configureVolumes(aiJob aiv1.Job, pod *corev1.PodSpec, containers ...[]corev1.Container) {
+	var mounts []corev1.VolumeMount
+	if aiJob.Spec.ShmSize != nil {
+		pod.Volumes = append(pod.Volumes, corev1.Volume{
+			Name: sharedMemoryVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{
+					Medium:    corev1.StorageMediumMemory,
+					SizeLimit: aiJob.Spec.ShmSize,
+				},
+			},
+		})
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      sharedMemoryVolumeName,
+			MountPath: sharedMemoryMountPath,
+		})
+	}
+
+	if aiJob.Spec.Scratch != nil {
+		for i := range pod.Volumes {
+			if pod.Volumes[i].Name == scratchVolumeName {
+				pod.Volumes[i].VolumeSource = scratchVolumeSource(*aiJob.Spec.Scratch)
+			}
+		}
+	}
+
+	for _, volume := range aiJob.Spec.ExtraVolumes {
+		pod.Volumes = append(pod.Volumes, *volume.DeepCopy())
+	}
+	mounts = append(mounts, aiJob.Spec.ExtraVolumeMounts...)
+
+	for _, containers := range containers {
+		for i := range containers {
+			containers[i].VolumeMounts = append(containers[i].VolumeMounts, mounts...)
+		}
+	}
+}
+
+// scratchVolumeSource returns the volume backing the scratch directory
+func scratchVolumeSource(scratch aiv1.JobScratch) corev1.VolumeSource {
+	if scratch.Type != aiv1.ScratchEphemeral {
+		return corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: scratch.Size},
+		}
+	}
+
+	var storageClassName *string
+	if scratch.StorageClassName != "" {
+		storageClassName = &scratch.StorageClassName
+	}
+	return corev1.VolumeSource{
+		Ephemeral: &corev1.EphemeralVolumeSource{
+			VolumeClaimTemplate: &corev1.PersistentVolumeClaimTemplate{
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					StorageClassName: storageClassName,
+					Resources: corev1.VolumeResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: *scratch.Size,
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Volumes", func() {
+	newPod := func(aiJob aiv1.Job) *corev1.PodSpec {
+		pod := &corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "download"}},
+			Containers:     []corev1.Container{{Name: "job"}},
+		}
+		configureSecurity(aiJob, pod)
+		return pod
+	}
+
+	It("should mount the shared memory and extra volumes in the given containers", func() {
+		size := resource.MustParse("8Gi")
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{
+			ShmSize: &size,
+			ExtraVolumes: []corev1.Volume{{
+				Name: "dataset",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "dataset"},
+				},
+			}},
+			ExtraVolumeMounts: []corev1.VolumeMount{{Name: "dataset", MountPath: "/data", ReadOnly: true}},
+		}}
+		pod := newPod(aiJob)
+		configureVolumes(aiJob, pod, pod.Containers)
+
+		Expect(pod.Volumes).To(ContainElement(corev1.Volume{
+			Name: sharedMemoryVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{
+				Medium:    corev1.StorageMediumMemory,
+				SizeLimit: &size,
+			}},
+		}))
+		Expect(pod.Volumes).To(ContainElement(aiJob.Spec.ExtraVolumes[0]))
+		Expect(pod.Containers[0].VolumeMounts).To(ContainElements(
+			corev1.VolumeMount{Name: sharedMemoryVolumeName, MountPath: sharedMemoryMountPath},
+			aiJob.Spec.ExtraVolumeMounts[0],
+		))
+		Expect(pod.InitContainers[0].VolumeMounts).To(HaveLen(1))
+	})
+
+	It("should limit the scratch directory", func() {
+		size := resource.MustParse("100Gi")
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{Scratch: &aiv1.JobScratch{Type: aiv1.ScratchEmptyDir, Size: &size}}}
+		pod := newPod(aiJob)
+		configureVolumes(aiJob, pod, pod.Containers)
+
+		Expect(pod.Volumes).To(ContainElement(corev1.Volume{
+			Name:         scratchVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &size}},
+		}))
+	})
+
+	It("should provision an ephemeral scratch volume", func() {
+		size := resource.MustParse("500Gi")
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{Scratch: &aiv1.JobScratch{
+			Type:             aiv1.ScratchEphemeral,
+			Size:             &size,
+			StorageClassName: "nvme",
+		}}}
+		pod := newPod(aiJob)
+		configureVolumes(aiJob, pod, pod.Containers)
+
+		var scratch corev1.Volume
+		for _, volume := range pod.Volumes {
+			if volume.Name == scratchVolumeName {
+				scratch = volume
+			}
+		}
+		Expect(scratch.EmptyDir).To(BeNil())
+		claim := scratch.Ephemeral.VolumeClaimTemplate.Spec
+		Expect(*claim.StorageClassName).To(Equal("nvme"))
+		Expect(claim.Resources.Requests).To(HaveKeyWithValue(corev1.ResourceStorage, size))
+	})
+})
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"path"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// jobWorkspace returns the layout of the job volume, as recorded when the job
+// started so that a changed spec still finds the model and checkpoints
+func jobWorkspace(aiJob aiv1.Job) aiv1.JobWorkspace {
+	if aiJob.Status.Workspace != nil {
+		return *aiJob.Status.Workspace
+	}
+	return aiJob.Spec.Workspace.Layout()
+}
+
+// workspacePath returns the path in the containers of a directory relative
+// to the job volume
+func workspacePath(aiJob aiv1.Job, dir ...string) string {
+	return path.Join(append([]string{jobWorkspace(aiJob).MountPath}, dir...)...)
+}
+
+// workspaceVolumeMount mounts the job volume at the root of the workspace
+func workspaceVolumeMount(aiJob aiv1.Job) corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      jobDefaultVolumeName,
+		MountPath: jobWorkspace(aiJob).MountPath,
+	}
+}
+
+// configureWorkspace exposes the layout of the job volume to all containers
+// of the pod
+func configureWorkspace(aiJob aiv1.Job, pod *corev1.PodSpec) {
+	ws := jobWorkspace(aiJob)
+	env := []corev1.EnvVar{
+		{Name: "WORKSPACE_DIR", Value: ws.MountPath},
+		{Name: "WORKSPACE_MODEL_DIR", Value: jobModelDir(aiJob)},
+		{Name: "WORKSPACE_DATA_DIR", Value: workspacePath(aiJob, ws.Data)},
+		{Name: "WORKSPACE_OUTPUT_DIR", Value: workspacePath(aiJob, ws.Output)},
+		{Name: "WORKSPACE_CHECKPOINT_DIR", Value: workspacePath(aiJob, ws.Checkpoints)},
+		{Name: "WORKSPACE_LOG_DIR", Value: workspacePath(aiJob, ws.Logs)},
+	}
+	for _, containers := range [][]corev1.Container{pod.InitContainers, pod.Containers} {
+		for i := range containers {
+			containers[i].Env = append(containers[i].Env, env...)
+		}
+	}
+}
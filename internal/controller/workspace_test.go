@@ -0,0 +1,55 @@
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Workspace", func() {
+	It("should expose the default layout to all containers", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{HuggingFaceSecret: "hf-token", Tracking: &aiv1.JobTracking{Provider: aiv1.TrackingProviderTensorBoard}}}
+		Expect(aiJob.Spec.Validate()).To(Succeed())
+
+		pod := &corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "download"}},
+			Containers:     []corev1.Container{{Name: "job"}},
+		}
+		configureWorkspace(aiJob, pod)
+		for _, container := range []corev1.Container{pod.InitContainers[0], pod.Containers[0]} {
+			Expect(container.Env).To(ConsistOf(
+				corev1.EnvVar{Name: "WORKSPACE_DIR", Value: "/workspace"},
+				corev1.EnvVar{Name: "WORKSPACE_MODEL_DIR", Value: "/workspace/model"},
+				corev1.EnvVar{Name: "WORKSPACE_DATA_DIR", Value: "/workspace/data"},
+				corev1.EnvVar{Name: "WORKSPACE_OUTPUT_DIR", Value: "/workspace/output"},
+				corev1.EnvVar{Name: "WORKSPACE_CHECKPOINT_DIR", Value: "/workspace/checkpoints"},
+				corev1.EnvVar{Name: "WORKSPACE_LOG_DIR", Value: "/workspace/logs"},
+			))
+		}
+		Expect(jobTensorBoardDir(aiJob)).To(Equal("/workspace/logs/tensorboard"))
+	})
+
+	It("should keep the layout the job started with", func() {
+		aiJob := aiv1.Job{Spec: aiv1.JobSpec{
+			HuggingFaceSecret: "hf-token",
+			Workspace:         &aiv1.JobWorkspace{MountPath: "/data", Output: "weights"},
+		}}
+		Expect(aiJob.Spec.Validate()).To(Succeed())
+		Expect(workspaceVolumeMount(aiJob).MountPath).To(Equal("/data"))
+		Expect(workspacePath(aiJob, jobWorkspace(aiJob).Output)).To(Equal("/data/weights"))
+
+		aiJob.Status.Workspace = aiJob.Spec.Workspace.DeepCopy()
+		aiJob.Spec.Workspace.Output = "other"
+		Expect(workspacePath(aiJob, jobWorkspace(aiJob).Output)).To(Equal("/data/weights"))
+	})
+
+	It("should keep the workspace on the job volume", func() {
+		spec := aiv1.JobSpec{Workspace: &aiv1.JobWorkspace{Model: "../model"}}
+		Expect(spec.Validate()).To(MatchError(ContainSubstring("relative to the job volume")))
+
+		spec = aiv1.JobSpec{Workspace: &aiv1.JobWorkspace{MountPath: "/scratch/workspace"}}
+		Expect(spec.Validate()).To(MatchError(ContainSubstring("cannot use /scratch")))
+	})
+})
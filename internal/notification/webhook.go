@@ -0,0 +1,193 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package notification delivers AI Job events to HTTP endpoints.
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const (
+	// Header carrying the HMAC-SHA256 signature of the body
+	SignatureHeader = "X-AI-Operator-Signature-256"
+
+	// Type of the CloudEvents sent for state changes
+	CloudEventType = "com.re-cinq.ai.job.state"
+)
+
+// Format is the payload format of a webhook.
+type Format string
+
+const (
+	// FormatJSON posts the event as JSON, or the rendered template
+	FormatJSON Format = "JSON"
+	// FormatSlack posts a Slack incoming webhook message
+	FormatSlack Format = "Slack"
+	// FormatCloudEvents posts a CloudEvent in binary content mode
+	FormatCloudEvents Format = "CloudEvents"
+)
+
+// Event is a state change of an AI Job.
+type Event struct {
+	ID        string    `json:"id"`
+	Job       string    `json:"job"`
+	Namespace string    `json:"namespace"`
+	UID       string    `json:"uid"`
+	State     string    `json:"state"`
+	Details   string    `json:"details,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	RunURL    string    `json:"runURL,omitempty"`
+	Time      time.Time `json:"time"`
+}
+
+// Webhook is an HTTP endpoint receiving events.
+type Webhook struct {
+	URL    string
+	Format Format
+
+	// Go template rendering the body of JSON webhooks, the event is the data
+	// with its strings escaped for JSON string literals
+	Template string
+
+	// Key signing the body, no signature is sent when empty
+	SigningKey []byte
+
+	// HTTP client used for the requests, defaults to one with a timeout
+	HTTPClient *http.Client
+}
+
+// Send delivers the event to the webhook
+func (w *Webhook) Send(ctx context.Context, event Event) error {
+	body, contentType, err := w.payload(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if w.Format == FormatCloudEvents {
+		req.Header.Set("ce-specversion", "1.0")
+		req.Header.Set("ce-id", event.ID)
+		req.Header.Set("ce-type", CloudEventType)
+		req.Header.Set("ce-source", fmt.Sprintf("/apis/ai.re-cinq.com/v1/namespaces/%s/jobs/%s", event.Namespace, event.Job))
+		req.Header.Set("ce-subject", event.State)
+		req.Header.Set("ce-time", event.Time.UTC().Format(time.RFC3339))
+	}
+	if len(w.SigningKey) > 0 {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(w.SigningKey, body))
+	}
+
+	httpClient := w.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return nil
+}
+
+// payload returns the body of the request and its content type
+func (w *Webhook) payload(event Event) ([]byte, string, error) {
+	switch w.Format {
+	case FormatSlack:
+		body, err := json.Marshal(map[string]string{"text": slackText(event)})
+		return body, "application/json", err
+	case FormatCloudEvents:
+		body, err := json.Marshal(event)
+		return body, "application/json", err
+	}
+
+	if w.Template == "" {
+		body, err := json.Marshal(event)
+		return body, "application/json", err
+	}
+	tmpl, err := template.New("payload").Option("missingkey=error").Parse(w.Template)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, escapeEvent(event)); err != nil {
+		return nil, "", fmt.Errorf("failed to render template: %w", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, "", fmt.Errorf("template did not render valid JSON")
+	}
+	return buf.Bytes(), "application/json", nil
+}
+
+// escapeEvent returns the event with its strings escaped to be placed in
+// JSON string literals, so details with quotes or newlines keep the payload
+// valid
+func escapeEvent(event Event) Event {
+	for _, field := range []*string{
+		&event.ID, &event.Job, &event.Namespace, &event.UID, &event.State,
+		&event.Details, &event.Model, &event.RunURL,
+	} {
+		*field = jsonEscape(*field)
+	}
+	return event
+}
+
+// jsonEscape returns the string as the content of a JSON string literal
+func jsonEscape(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data[1 : len(data)-1])
+}
+
+// slackText summarizes the event for humans
+func slackText(event Event) string {
+	text := fmt.Sprintf("AI Job *%s/%s* is *%s*", event.Namespace, event.Job, event.State)
+	if event.Model != "" {
+		text += fmt.Sprintf(" (model `%s`)", event.Model)
+	}
+	if event.Details != "" {
+		text += ": " + event.Details
+	}
+	if event.RunURL != "" {
+		text += fmt.Sprintf("\n<%s|Training run>", event.RunURL)
+	}
+	return text
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the body
+func Sign(key, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
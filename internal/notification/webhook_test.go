@@ -0,0 +1,114 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Webhook", func() {
+	var (
+		server   *httptest.Server
+		requests chan *http.Request
+		bodies   chan []byte
+		status   int
+	)
+
+	event := Event{
+		ID:        "uid-Succeeded",
+		Job:       "finetune",
+		Namespace: "team-a",
+		UID:       "uid",
+		State:     "Succeeded",
+		Model:     "Qwen/Qwen2.5-0.5B-Instruct",
+		Time:      time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	BeforeEach(func() {
+		requests = make(chan *http.Request, 1)
+		bodies = make(chan []byte, 1)
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			requests <- r
+			bodies <- body
+			w.WriteHeader(status)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should post the event as signed JSON", func() {
+		webhook := &Webhook{URL: server.URL, Format: FormatJSON, SigningKey: []byte("key")}
+		Expect(webhook.Send(context.Background(), event)).To(Succeed())
+
+		req, body := <-requests, <-bodies
+		Expect(req.Header.Get(SignatureHeader)).To(Equal("sha256=" + Sign([]byte("key"), body)))
+		var received Event
+		Expect(json.Unmarshal(body, &received)).To(Succeed())
+		Expect(received).To(Equal(event))
+	})
+
+	It("should render the template", func() {
+		webhook := &Webhook{URL: server.URL, Format: FormatJSON, Template: `{"msg":"{{.Job}} {{.State}}"}`}
+		Expect(webhook.Send(context.Background(), event)).To(Succeed())
+
+		<-requests
+		Expect(string(<-bodies)).To(Equal(`{"msg":"finetune Succeeded"}`))
+	})
+
+	It("should escape the event in the template", func() {
+		escaped := event
+		escaped.Details = "Failed: \"loss\" is NaN\n\tat step 3"
+		webhook := &Webhook{URL: server.URL, Format: FormatJSON, Template: `{"details":"{{.Details}}"}`}
+		Expect(webhook.Send(context.Background(), escaped)).To(Succeed())
+
+		<-requests
+		var received map[string]string
+		Expect(json.Unmarshal(<-bodies, &received)).To(Succeed())
+		Expect(received["details"]).To(Equal(escaped.Details))
+	})
+
+	It("should reject a template not rendering JSON", func() {
+		webhook := &Webhook{URL: server.URL, Format: FormatJSON, Template: `{"job":{{.Job}}}`}
+		Expect(webhook.Send(context.Background(), event)).To(MatchError(ContainSubstring("valid JSON")))
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("should post a Slack message", func() {
+		webhook := &Webhook{URL: server.URL, Format: FormatSlack}
+		Expect(webhook.Send(context.Background(), event)).To(Succeed())
+
+		<-requests
+		var message map[string]string
+		Expect(json.Unmarshal(<-bodies, &message)).To(Succeed())
+		Expect(message["text"]).To(ContainSubstring("*team-a/finetune* is *Succeeded*"))
+	})
+
+	It("should post a binary CloudEvent", func() {
+		webhook := &Webhook{URL: server.URL, Format: FormatCloudEvents}
+		Expect(webhook.Send(context.Background(), event)).To(Succeed())
+
+		req := <-requests
+		<-bodies
+		Expect(req.Header.Get("ce-specversion")).To(Equal("1.0"))
+		Expect(req.Header.Get("ce-id")).To(Equal("uid-Succeeded"))
+		Expect(req.Header.Get("ce-type")).To(Equal(CloudEventType))
+		Expect(req.Header.Get("ce-source")).To(Equal("/apis/ai.re-cinq.com/v1/namespaces/team-a/jobs/finetune"))
+		Expect(req.Header.Get("ce-time")).To(Equal("2025-01-01T12:00:00Z"))
+	})
+
+	It("should fail on error responses", func() {
+		status = http.StatusServiceUnavailable
+		webhook := &Webhook{URL: server.URL}
+		Expect(webhook.Send(context.Background(), event)).To(MatchError(ContainSubstring("503")))
+	})
+})
@@ -0,0 +1,245 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package policy checks AI Jobs against the AIJobPolicies of their namespace
+// and the ClusterAIJobPolicies.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/registry"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Evaluate checks the AI Job against the policies that apply to it. The
+// violations of audited policies are returned as warnings, the ones of
+// enforced policies as an error listing all of them.
+func Evaluate(ctx context.Context, reader client.Reader, aiJob aiv1.Job) ([]string, error) {
+	var policies aiv1.AIJobPolicyList
+	if err := reader.List(ctx, &policies, client.InNamespace(aiJob.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list AIJobPolicies: %w", err)
+	}
+	var clusterPolicies aiv1.ClusterAIJobPolicyList
+	if err := reader.List(ctx, &clusterPolicies); err != nil {
+		return nil, fmt.Errorf("failed to list ClusterAIJobPolicies: %w", err)
+	}
+
+	var warnings, denials []string
+	check := func(kind, name string, spec aiv1.AIJobPolicySpec) {
+		violations := Violations(spec, aiJob)
+		if len(violations) == 0 {
+			return
+		}
+		message := fmt.Sprintf("%s %s: %s", kind, name, strings.Join(violations, "; "))
+		if spec.Mode == aiv1.PolicyModeAudit {
+			warnings = append(warnings, fmt.Sprintf("audit of %s", message))
+		} else {
+			denials = append(denials, message)
+		}
+	}
+	for _, policy := range clusterPolicies.Items {
+		check("ClusterAIJobPolicy", policy.Name, policy.Spec)
+	}
+	for _, policy := range policies.Items {
+		check("AIJobPolicy", policy.Name, policy.Spec)
+	}
+
+	if len(denials) > 0 {
+		return warnings, fmt.Errorf("denied by %s", strings.Join(denials, ", "))
+	}
+	return warnings, nil
+}
+
+// Violations returns why the AI Job does not follow the policy, in words
+// meant for its author. The spec of the job must be validated.
+func Violations(policy aiv1.AIJobPolicySpec, aiJob aiv1.Job) []string {
+	spec := aiJob.Spec
+	var violations []string
+
+	if len(policy.AllowedImages) > 0 {
+		for _, image := range spec.Images() {
+			if image != "" && !slices.ContainsFunc(policy.AllowedImages, func(prefix string) bool {
+				return registry.HasPrefix(image, prefix)
+			}) {
+				violations = append(violations, fmt.Sprintf("image %s is not allowed, use one of %s", image, strings.Join(policy.AllowedImages, ", ")))
+			}
+		}
+	}
+
+	if len(policy.AllowedModels) > 0 && !slices.ContainsFunc(policy.AllowedModels, func(pattern string) bool {
+		matched, err := path.Match(pattern, spec.Model)
+		return err == nil && matched
+	}) {
+		violations = append(violations, fmt.Sprintf("model %s is not allowed, use one of %s", spec.Model, strings.Join(policy.AllowedModels, ", ")))
+	}
+
+	if policy.MaxGPUs > 0 && spec.GPUs > policy.MaxGPUs {
+		violations = append(violations, fmt.Sprintf("%d GPUs exceed the maximum of %d", spec.GPUs, policy.MaxGPUs))
+	}
+	if policy.MaxDiskSize > 0 && spec.DiskSize > policy.MaxDiskSize {
+		violations = append(violations, fmt.Sprintf("disk size %d exceeds the maximum of %d", spec.DiskSize, policy.MaxDiskSize))
+	}
+
+	if len(policy.AllowedStorageClasses) > 0 && !slices.Contains(policy.AllowedStorageClasses, spec.StorageClassName) {
+		violations = append(violations, fmt.Sprintf("storage class %s is not allowed, use one of %s", spec.StorageClassName, strings.Join(policy.AllowedStorageClasses, ", ")))
+	}
+	if len(policy.AllowedRuntimeClasses) > 0 && !slices.Contains(policy.AllowedRuntimeClasses, spec.RuntimeClassName) {
+		violations = append(violations, fmt.Sprintf("runtime class %s is not allowed, use one of %s", spec.RuntimeClassName, strings.Join(policy.AllowedRuntimeClasses, ", ")))
+	}
+
+	if policy.RestrictPrivileges {
+		violations = append(violations, privilegeViolations(spec, policy.AllowedCapabilities)...)
+	}
+
+	var missing []string
+	for _, label := range policy.RequiredLabels {
+		if _, ok := aiJob.Labels[label]; !ok {
+			missing = append(missing, label)
+		}
+	}
+	if len(missing) > 0 {
+		violations = append(violations, fmt.Sprintf("labels %s are required", strings.Join(missing, ", ")))
+	}
+
+	return violations
+}
+
+// Sysctls of the restricted Pod Security Standard, namespaced and isolated
+// from the node
+var safeSysctls = []string{
+	"kernel.shm_rmid_forced",
+	"net.ipv4.ip_local_port_range",
+	"net.ipv4.ip_local_reserved_ports",
+	"net.ipv4.ip_unprivileged_port_start",
+	"net.ipv4.ping_group_range",
+	"net.ipv4.tcp_fin_timeout",
+	"net.ipv4.tcp_keepalive_intvl",
+	"net.ipv4.tcp_keepalive_probes",
+	"net.ipv4.tcp_keepalive_time",
+	"net.ipv4.tcp_syncookies",
+}
+
+// SELinux types of the restricted Pod Security Standard
+var safeSELinuxTypes = []string{"", "container_t", "container_init_t", "container_kvm_t", "container_engine_t"}
+
+// privilegeViolations returns how the security contexts and the host network
+// of the job raise the privileges of its pods over the defaults of the
+// operator, which only the fields set by the job can do. It follows the
+// restricted Pod Security Standard, and also rejects the root group.
+func privilegeViolations(spec aiv1.JobSpec, allowedCapabilities []corev1.Capability) []string {
+	var violations []string
+	add := func(violation string) {
+		if !slices.Contains(violations, violation) {
+			violations = append(violations, violation)
+		}
+	}
+
+	// Fields of both security contexts, the container one overriding the pod one
+	var (
+		root, rootGroup bool
+		seccomp         []*corev1.SeccompProfile
+		appArmor        []*corev1.AppArmorProfile
+		seLinux         []*corev1.SELinuxOptions
+		windows         []*corev1.WindowsSecurityContextOptions
+	)
+	if pod := spec.PodSecurityContext; pod != nil {
+		root = (pod.RunAsUser != nil && *pod.RunAsUser == 0) || (pod.RunAsNonRoot != nil && !*pod.RunAsNonRoot)
+		rootGroup = pod.RunAsGroup != nil && *pod.RunAsGroup == 0
+		seccomp = append(seccomp, pod.SeccompProfile)
+		appArmor = append(appArmor, pod.AppArmorProfile)
+		seLinux = append(seLinux, pod.SELinuxOptions)
+		windows = append(windows, pod.WindowsOptions)
+	}
+	container := spec.SecurityContext
+	if container != nil {
+		if container.Privileged != nil && *container.Privileged {
+			add("privileged containers are not allowed")
+		}
+		if container.AllowPrivilegeEscalation != nil && *container.AllowPrivilegeEscalation {
+			add("privilege escalation is not allowed")
+		}
+		root = root || (container.RunAsUser != nil && *container.RunAsUser == 0) ||
+			(container.RunAsNonRoot != nil && !*container.RunAsNonRoot)
+		rootGroup = rootGroup || (container.RunAsGroup != nil && *container.RunAsGroup == 0)
+		if capabilities := container.Capabilities; capabilities != nil {
+			for _, capability := range capabilities.Add {
+				if !slices.Contains(allowedCapabilities, capability) {
+					add(fmt.Sprintf("capability %s is not allowed", capability))
+				}
+			}
+			if capabilities.Drop != nil && !slices.Contains(capabilities.Drop, "ALL") {
+				add("containers must drop ALL capabilities")
+			}
+		}
+		seccomp = append(seccomp, container.SeccompProfile)
+		appArmor = append(appArmor, container.AppArmorProfile)
+		seLinux = append(seLinux, container.SELinuxOptions)
+		windows = append(windows, container.WindowsOptions)
+	}
+	if root {
+		add("running as root is not allowed")
+	}
+	if rootGroup {
+		add("running as the root group is not allowed")
+	}
+	for _, profile := range seccomp {
+		if profile != nil && profile.Type == corev1.SeccompProfileTypeUnconfined {
+			add("seccomp profile Unconfined is not allowed")
+		}
+	}
+	for _, profile := range appArmor {
+		if profile != nil && profile.Type == corev1.AppArmorProfileTypeUnconfined {
+			add("AppArmor profile Unconfined is not allowed")
+		}
+	}
+	for _, options := range seLinux {
+		if options == nil {
+			continue
+		}
+		if !slices.Contains(safeSELinuxTypes, options.Type) {
+			add(fmt.Sprintf("SELinux type %s is not allowed", options.Type))
+		}
+		if options.User != "" || options.Role != "" {
+			add("SELinux user and role cannot be set")
+		}
+	}
+	if container != nil && container.ProcMount != nil && *container.ProcMount == corev1.UnmaskedProcMount {
+		add("unmasked /proc mount is not allowed")
+	}
+	for _, options := range windows {
+		if options != nil && options.HostProcess != nil && *options.HostProcess {
+			add("host processes are not allowed")
+		}
+	}
+	if pod := spec.PodSecurityContext; pod != nil {
+		for _, sysctl := range pod.Sysctls {
+			if !slices.Contains(safeSysctls, sysctl.Name) {
+				add(fmt.Sprintf("sysctl %s is not allowed", sysctl.Name))
+			}
+		}
+	}
+	if spec.Networking != nil && spec.Networking.HostNetwork {
+		add("host network is not allowed")
+	}
+	return violations
+}
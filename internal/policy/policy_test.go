@@ -0,0 +1,169 @@
+package policy
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Policy", func() {
+	aiJob := aiv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "job",
+			Namespace: "team-a",
+			Labels:    map[string]string{"team": "a"},
+		},
+		Spec: aiv1.JobSpec{
+			Image:            "docker.io/someone/torchtune:latest",
+			Model:            "Qwen/Qwen2.5-0.5B-Instruct",
+			GPUs:             8,
+			DiskSize:         50,
+			StorageClassName: "local-path",
+			RuntimeClassName: "nvidia",
+			Postprocess: []aiv1.PostprocessStep{
+				{Name: "gguf", Type: aiv1.PostprocessConvertGGUF, Image: "registry.internal/llama.cpp@sha256:0123"},
+			},
+		},
+	}
+
+	It("should allow everything without rules", func() {
+		Expect(Violations(aiv1.AIJobPolicySpec{}, aiJob)).To(BeEmpty())
+	})
+
+	It("should explain every violation", func() {
+		violations := Violations(aiv1.AIJobPolicySpec{
+			AllowedImages:         []string{"registry.internal/"},
+			AllowedModels:         []string{"meta-llama/*"},
+			MaxGPUs:               4,
+			MaxDiskSize:           20,
+			AllowedStorageClasses: []string{"fast-ssd"},
+			AllowedRuntimeClasses: []string{"nvidia"},
+			RequiredLabels:        []string{"team", "cost-center"},
+		}, aiJob)
+		Expect(violations).To(Equal([]string{
+			"image docker.io/someone/torchtune:latest is not allowed, use one of registry.internal/",
+			"model Qwen/Qwen2.5-0.5B-Instruct is not allowed, use one of meta-llama/*",
+			"8 GPUs exceed the maximum of 4",
+			"disk size 50 exceeds the maximum of 20",
+			"storage class local-path is not allowed, use one of fast-ssd",
+			"labels cost-center are required",
+		}))
+	})
+
+	It("should allow images pinned by digest and models matching a pattern", func() {
+		Expect(Violations(aiv1.AIJobPolicySpec{
+			AllowedImages: []string{"docker.io/someone/", "registry.internal/llama.cpp@sha256:0123"},
+			AllowedModels: []string{"Qwen/*"},
+		}, aiJob)).To(BeEmpty())
+	})
+
+	It("should not allow images of lookalike hosts", func() {
+		lookalike := *aiJob.DeepCopy()
+		lookalike.Spec.Image = "docker.io/someone.evil.io/trainer:v1"
+		Expect(Violations(aiv1.AIJobPolicySpec{AllowedImages: []string{"docker.io/someone"}}, lookalike)).To(ContainElement(
+			"image docker.io/someone.evil.io/trainer:v1 is not allowed, use one of docker.io/someone",
+		))
+	})
+
+	It("should restrict the privileges raised by the security contexts", func() {
+		policy := aiv1.AIJobPolicySpec{RestrictPrivileges: true, AllowedCapabilities: []corev1.Capability{"IPC_LOCK"}}
+		Expect(Violations(policy, aiJob)).To(BeEmpty())
+
+		root, yes, no := int64(0), true, false
+		privileged := *aiJob.DeepCopy()
+		privileged.Spec.PodSecurityContext = &corev1.PodSecurityContext{RunAsUser: &root}
+		privileged.Spec.SecurityContext = &corev1.SecurityContext{
+			Privileged:               &yes,
+			AllowPrivilegeEscalation: &yes,
+			RunAsNonRoot:             &no,
+			Capabilities: &corev1.Capabilities{
+				Add:  []corev1.Capability{"IPC_LOCK", "SYS_ADMIN"},
+				Drop: []corev1.Capability{"NET_RAW"},
+			},
+		}
+		privileged.Spec.Networking = &aiv1.JobNetworking{HostNetwork: true}
+		Expect(Violations(policy, privileged)).To(Equal([]string{
+			"privileged containers are not allowed",
+			"privilege escalation is not allowed",
+			"capability SYS_ADMIN is not allowed",
+			"containers must drop ALL capabilities",
+			"running as root is not allowed",
+			"host network is not allowed",
+		}))
+		Expect(Violations(aiv1.AIJobPolicySpec{}, privileged)).To(BeEmpty())
+	})
+
+	DescribeTable("should restrict the privileges like the restricted Pod Security Standard",
+		func(pod *corev1.PodSecurityContext, container *corev1.SecurityContext, violation string) {
+			restricted := *aiJob.DeepCopy()
+			restricted.Spec.PodSecurityContext = pod
+			restricted.Spec.SecurityContext = container
+			Expect(Violations(aiv1.AIJobPolicySpec{RestrictPrivileges: true}, restricted)).To(Equal([]string{violation}))
+		},
+		Entry("root group of the pod", &corev1.PodSecurityContext{RunAsGroup: new(int64)}, nil,
+			"running as the root group is not allowed"),
+		Entry("root group of the container", nil, &corev1.SecurityContext{RunAsGroup: new(int64)},
+			"running as the root group is not allowed"),
+		Entry("unconfined seccomp profile",
+			&corev1.PodSecurityContext{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}}, nil,
+			"seccomp profile Unconfined is not allowed"),
+		Entry("unconfined AppArmor profile", nil,
+			&corev1.SecurityContext{AppArmorProfile: &corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeUnconfined}},
+			"AppArmor profile Unconfined is not allowed"),
+		Entry("SELinux type", nil, &corev1.SecurityContext{SELinuxOptions: &corev1.SELinuxOptions{Type: "spc_t"}},
+			"SELinux type spc_t is not allowed"),
+		Entry("SELinux user", &corev1.PodSecurityContext{SELinuxOptions: &corev1.SELinuxOptions{User: "system_u"}}, nil,
+			"SELinux user and role cannot be set"),
+		Entry("unmasked /proc", nil, &corev1.SecurityContext{ProcMount: ptrTo(corev1.UnmaskedProcMount)},
+			"unmasked /proc mount is not allowed"),
+		Entry("host process", nil,
+			&corev1.SecurityContext{WindowsOptions: &corev1.WindowsSecurityContextOptions{HostProcess: ptrTo(true)}},
+			"host processes are not allowed"),
+		Entry("unsafe sysctl", &corev1.PodSecurityContext{Sysctls: []corev1.Sysctl{
+			{Name: "net.ipv4.tcp_syncookies", Value: "1"},
+			{Name: "kernel.msgmax", Value: "65536"},
+		}}, nil, "sysctl kernel.msgmax is not allowed"),
+	)
+
+	It("should deny enforced policies and warn about audited ones", func() {
+		scheme := runtime.NewScheme()
+		Expect(aiv1.AddToScheme(scheme)).To(Succeed())
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&aiv1.ClusterAIJobPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "gpus"},
+				Spec:       aiv1.AIJobPolicySpec{MaxGPUs: 4},
+			},
+			&aiv1.AIJobPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "team-a"},
+				Spec:       aiv1.AIJobPolicySpec{Mode: aiv1.PolicyModeAudit, AllowedModels: []string{"meta-llama/*"}},
+			},
+			&aiv1.AIJobPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "disk", Namespace: "team-b"},
+				Spec:       aiv1.AIJobPolicySpec{MaxDiskSize: 10},
+			},
+		).Build()
+
+		warnings, err := Evaluate(context.Background(), reader, aiJob)
+		Expect(err).To(MatchError("denied by ClusterAIJobPolicy gpus: 8 GPUs exceed the maximum of 4"))
+		Expect(warnings).To(Equal([]string{
+			"audit of AIJobPolicy models: model Qwen/Qwen2.5-0.5B-Instruct is not allowed, use one of meta-llama/*",
+		}))
+
+		allowed := *aiJob.DeepCopy()
+		allowed.Spec.GPUs = 2
+		_, err = Evaluate(context.Background(), reader, allowed)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+// ptrTo returns a pointer to the value
+func ptrTo[T any](value T) *T {
+	return &value
+}
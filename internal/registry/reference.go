@@ -0,0 +1,112 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Registry of the image names without host
+	dockerHubRegistry = "docker.io"
+	// Host serving the Docker Hub API
+	dockerHubAPIHost = "registry-1.docker.io"
+	// Token service of the Docker Hub
+	dockerHubTokenHost = "auth.docker.io"
+)
+
+// digestRegexp matches the content digests of the OCI distribution spec
+var digestRegexp = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]+$`)
+
+// Reference is a parsed image name.
+type Reference struct {
+	// Name of the image as written, without tag or digest
+	Name string
+
+	// Host of the registry, docker.io for names without host
+	Registry string
+
+	// Repository in the registry
+	Repository string
+
+	// Tag, latest when neither a tag nor a digest is set
+	Tag string
+
+	// Digest the image is pinned to, if any
+	Digest string
+}
+
+// ParseReference splits an image name like registry:5000/team/image:tag or
+// image@sha256:... into its parts
+func ParseReference(image string) (Reference, error) {
+	ref := Reference{Name: image}
+	if name, digest, ok := strings.Cut(image, "@"); ok {
+		if !digestRegexp.MatchString(digest) {
+			return Reference{}, fmt.Errorf("invalid digest in image %s", image)
+		}
+		ref.Name, ref.Digest = name, digest
+	}
+	if i := strings.LastIndex(ref.Name, ":"); i > strings.LastIndex(ref.Name, "/") {
+		ref.Name, ref.Tag = ref.Name[:i], ref.Name[i+1:]
+	}
+	if ref.Name == "" || strings.ToLower(ref.Name) != ref.Name {
+		return Reference{}, fmt.Errorf("invalid image name %s", image)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	// The first component is a host when it looks like one
+	host, repository, ok := strings.Cut(ref.Name, "/")
+	if ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		ref.Registry, ref.Repository = host, repository
+	} else {
+		ref.Registry, ref.Repository = dockerHubRegistry, ref.Name
+		if !ok {
+			ref.Repository = "library/" + ref.Name
+		}
+	}
+	return ref, nil
+}
+
+// Pinned returns the image name pinned to the digest
+func (r Reference) Pinned(digest string) string {
+	return r.Name + "@" + digest
+}
+
+// apiHost returns the host serving the registry API
+func (r Reference) apiHost() string {
+	if r.Registry == dockerHubRegistry {
+		return dockerHubAPIHost
+	}
+	return r.Registry
+}
+
+// HasPrefix reports whether the image name starts with the prefix, which ends
+// at a component boundary: registry.example.com matches
+// registry.example.com/team/image but not registry.example.com.evil.io/image
+func HasPrefix(image, prefix string) bool {
+	if prefix == "" || !strings.HasPrefix(image, prefix) {
+		return false
+	}
+	if len(image) == len(prefix) || strings.ContainsAny(prefix[len(prefix)-1:], "/:@") {
+		return true
+	}
+	return strings.ContainsAny(image[len(prefix):len(prefix)+1], "/:@")
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package registry resolves image tags to digests through the OCI
+// distribution API, so that the pods of a job run the image it started with.
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// Time allowed to resolve a digest, token exchanges included
+const digestTimeout = 30 * time.Second
+
+// Credential authenticates to a registry.
+type Credential struct {
+	Username string
+	Password string
+}
+
+// Keychain holds the credentials of each registry host.
+type Keychain map[string]Credential
+
+// Resolve returns the credentials of the registry of the resource, anonymous
+// when there are none
+func (k Keychain) Resolve(resource authn.Resource) (authn.Authenticator, error) {
+	credential, ok := k[registryHost(resource.RegistryStr())]
+	if !ok {
+		return authn.Anonymous, nil
+	}
+	return authn.FromConfig(authn.AuthConfig{Username: credential.Username, Password: credential.Password}), nil
+}
+
+// dockerConfig is the content of a kubernetes.io/dockerconfigjson secret
+type dockerConfig struct {
+	Auths map[string]struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Auth     string `json:"auth"`
+	} `json:"auths"`
+}
+
+// AddDockerConfig adds the credentials of a .dockerconfigjson file, the
+// format of image pull secrets
+func (k Keychain) AddDockerConfig(data []byte) error {
+	config := dockerConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("invalid docker config: %w", err)
+	}
+	for server, auth := range config.Auths {
+		credential := Credential{Username: auth.Username, Password: auth.Password}
+		if auth.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+			if err != nil {
+				return fmt.Errorf("invalid auth of %s: %w", server, err)
+			}
+			credential.Username, credential.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		k[registryHost(server)] = credential
+	}
+	return nil
+}
+
+// registryHost returns the host of a docker config server, which may be a URL
+func registryHost(server string) string {
+	if u, err := url.Parse(server); err == nil && u.Host != "" {
+		server = u.Host
+	}
+	switch server {
+	case name.DefaultRegistry, dockerHubAPIHost:
+		return dockerHubRegistry
+	}
+	return server
+}
+
+// Client reads manifests from registries.
+type Client struct {
+	// Registry hosts reached over plain HTTP. Loopback and private hosts
+	// always are.
+	Insecure []string
+
+	// Token service hosts trusted with the credentials of every registry.
+	// Token services only get the credentials of their own registry host
+	// otherwise, or of the Docker Hub for its token service.
+	TokenHosts []string
+
+	// Transport of the requests, the default one when nil
+	Transport http.RoundTripper
+}
+
+// Digest returns the digest of the manifest the image points to, which is
+// the digest of the image itself when it is pinned already
+func (c *Client) Digest(ctx context.Context, image string, keychain Keychain) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+
+	var opts []name.Option
+	if slices.Contains(c.Insecure, ref.Registry) {
+		opts = append(opts, name.Insecure)
+	}
+	tag, err := name.NewTag(ref.Name+":"+ref.Tag, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, digestTimeout)
+	defer cancel()
+	descriptor, err := remote.Head(tag,
+		remote.WithContext(ctx),
+		remote.WithAuthFromKeychain(keychain),
+		remote.WithTransport(&guardedTransport{client: c, ref: ref}),
+	)
+	if err != nil {
+		return "", fmt.Errorf("registry %s: %w", ref.Registry, err)
+	}
+	return descriptor.Digest.String(), nil
+}
+
+// guardedTransport keeps the registry from sending the credentials elsewhere
+// through the realm of its token service: hosts are only reached over plain
+// HTTP when the registry itself would be, and only the registry and the
+// trusted token services receive the credentials.
+type guardedTransport struct {
+	client *Client
+	ref    Reference
+}
+
+func (t *guardedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	host := request.URL.Host
+	if request.URL.Scheme != "https" && t.client.scheme(host) != request.URL.Scheme {
+		return nil, fmt.Errorf("%s is not served over HTTPS", request.URL.Redacted())
+	}
+	if request.Header.Get("Authorization") != "" && !t.client.trustedHost(host, t.ref) {
+		request = request.Clone(request.Context())
+		request.Header.Del("Authorization")
+	}
+	if t.client.Transport != nil {
+		return t.client.Transport.RoundTrip(request)
+	}
+	return remote.DefaultTransport.RoundTrip(request)
+}
+
+// trustedHost reports whether the host may receive the credentials of the
+// registry of the reference
+func (c *Client) trustedHost(host string, ref Reference) bool {
+	return host == ref.Registry || host == ref.apiHost() ||
+		(ref.Registry == dockerHubRegistry && host == dockerHubTokenHost) ||
+		slices.Contains(c.TokenHosts, host)
+}
+
+// scheme returns the scheme the host is reached with, like the registries
+func (c *Client) scheme(host string) string {
+	var opts []name.Option
+	if slices.Contains(c.Insecure, host) {
+		opts = append(opts, name.Insecure)
+	}
+	registry, err := name.NewRegistry(host, opts...)
+	if err != nil {
+		return "https"
+	}
+	return registry.Scheme()
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const testDigest = "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// newFakeRegistry serves the manifest of team/trainer:v1 to clients holding
+// a token of its token service, which requires the credentials user:secret
+func newFakeRegistry() *httptest.Server {
+	mux := http.NewServeMux()
+	var server *httptest.Server
+	mux.HandleFunc("GET /token", func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "user" || password != "secret" ||
+			r.URL.Query().Get("scope") != "repository:team/trainer:pull" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": "pull-token"})
+	})
+	challenge := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="fake"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+	mux.HandleFunc("GET /v2/", challenge)
+	mux.HandleFunc("HEAD /v2/team/trainer/manifests/{tag}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			challenge(w, r)
+			return
+		}
+		if r.PathValue("tag") != "v1" || !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		writeManifestHead(w)
+	})
+	server = httptest.NewServer(mux)
+	return server
+}
+
+// writeManifestHead answers a HEAD request of the manifest of testDigest
+func writeManifestHead(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+	w.Header().Set("Content-Length", "512")
+	w.Header().Set("Docker-Content-Digest", testDigest)
+}
+
+var _ = Describe("Reference", func() {
+	It("should parse image names", func() {
+		for image, expected := range map[string]Reference{
+			"busybox": {Name: "busybox", Registry: "docker.io", Repository: "library/busybox", Tag: "latest"},
+			"silentehrec/torchtune:latest": {
+				Name: "silentehrec/torchtune", Registry: "docker.io", Repository: "silentehrec/torchtune", Tag: "latest",
+			},
+			"localhost:5000/trainer:v1": {
+				Name: "localhost:5000/trainer", Registry: "localhost:5000", Repository: "trainer", Tag: "v1",
+			},
+			"ghcr.io/org/image:v1@" + testDigest: {
+				Name: "ghcr.io/org/image", Registry: "ghcr.io", Repository: "org/image", Tag: "v1", Digest: testDigest,
+			},
+		} {
+			ref, err := ParseReference(image)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ref).To(Equal(expected))
+		}
+		Expect(Reference{Name: "ghcr.io/org/image"}.Pinned(testDigest)).To(Equal("ghcr.io/org/image@" + testDigest))
+	})
+
+	It("should match prefixes at component boundaries", func() {
+		Expect(HasPrefix("registry.example.com/team/image:v1", "registry.example.com/")).To(BeTrue())
+		Expect(HasPrefix("registry.example.com/team/image:v1", "registry.example.com")).To(BeTrue())
+		Expect(HasPrefix("registry.example.com:5000/image", "registry.example.com")).To(BeTrue())
+		Expect(HasPrefix("registry.example.com", "registry.example.com")).To(BeTrue())
+		Expect(HasPrefix("registry.example.com.evil.io/image", "registry.example.com")).To(BeFalse())
+		Expect(HasPrefix("registry.example.com/team-evil/image", "registry.example.com/team")).To(BeFalse())
+		Expect(HasPrefix("image", "")).To(BeFalse())
+	})
+
+	It("should reject invalid names", func() {
+		for _, image := range []string{"", "Image:v1", "image@latest"} {
+			_, err := ParseReference(image)
+			Expect(err).To(HaveOccurred())
+		}
+	})
+})
+
+var _ = Describe("Client", func() {
+	var server *httptest.Server
+	var image string
+
+	BeforeEach(func() {
+		server = newFakeRegistry()
+		image = strings.TrimPrefix(server.URL, "http://") + "/team/trainer:v1"
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should resolve a tag with the credentials of a pull secret", func() {
+		keychain := Keychain{}
+		host := strings.TrimPrefix(server.URL, "http://")
+		Expect(keychain.AddDockerConfig([]byte(`{"auths":{"` + host + `":{"auth":"dXNlcjpzZWNyZXQ="}}}`))).To(Succeed())
+
+		digest, err := (&Client{}).Digest(context.Background(), image, keychain)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal(testDigest))
+	})
+
+	It("should fail without credentials", func() {
+		_, err := (&Client{}).Digest(context.Background(), image, nil)
+		Expect(err).To(MatchError(ContainSubstring("401")))
+	})
+
+	It("should fail on unknown tags", func() {
+		keychain := Keychain{strings.TrimPrefix(server.URL, "http://"): {Username: "user", Password: "secret"}}
+		_, err := (&Client{}).Digest(context.Background(), strings.Replace(image, ":v1", ":v2", 1), keychain)
+		Expect(err).To(MatchError(ContainSubstring("404")))
+	})
+
+	It("should only send the credentials to the token service of the registry", func() {
+		received := ""
+		tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Get("Authorization")
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "pull-token"})
+		}))
+		defer tokens.Close()
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer pull-token" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+tokens.URL+`/token"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			writeManifestHead(w)
+		}))
+		defer other.Close()
+		host := strings.TrimPrefix(other.URL, "http://")
+		keychain := Keychain{host: {Username: "user", Password: "secret"}}
+
+		digest, err := (&Client{}).Digest(context.Background(), host+"/team/trainer:v1", keychain)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal(testDigest))
+		Expect(received).To(BeEmpty())
+
+		client := &Client{TokenHosts: []string{strings.TrimPrefix(tokens.URL, "http://")}}
+		_, err = client.Digest(context.Background(), host+"/team/trainer:v1", keychain)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(received).To(HavePrefix("Basic "))
+	})
+
+	It("should only request tokens over HTTPS", func() {
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="http://auth.example.com/token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer other.Close()
+
+		_, err := (&Client{}).Digest(context.Background(), strings.TrimPrefix(other.URL, "http://")+"/team/trainer:v1", nil)
+		Expect(err).To(MatchError(ContainSubstring("not served over HTTPS")))
+	})
+
+	It("should return the digest of pinned images without contacting the registry", func() {
+		digest, err := (&Client{}).Digest(context.Background(), "unreachable.invalid/image@"+testDigest, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal(testDigest))
+	})
+
+	It("should map the Docker Hub servers of docker configs", func() {
+		keychain := Keychain{}
+		Expect(keychain.AddDockerConfig([]byte(`{"auths":{"https://index.docker.io/v1/":{"username":"u","password":"p"}}}`))).To(Succeed())
+		Expect(keychain).To(HaveKeyWithValue("docker.io", Credential{Username: "u", Password: "p"}))
+	})
+})
@@ -0,0 +1,87 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package template merges AI Jobs over their JobTemplate or
+// ClusterJobTemplate.
+package template
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Resolve returns the spec of the AI Job merged over its template, and the
+// template it was merged with. Jobs without a template are returned as is.
+func Resolve(ctx context.Context, reader client.Reader, aiJob aiv1.Job) (aiv1.JobSpec, *aiv1.TemplateStatus, error) {
+	ref := aiJob.Spec.TemplateRef
+	if ref == nil {
+		return aiJob.Spec, nil, nil
+	}
+
+	status := &aiv1.TemplateStatus{Kind: ref.Kind, Name: ref.Name}
+	var template aiv1.JobTemplateSpec
+	switch ref.Kind {
+	case aiv1.TemplateKindJobTemplate, "":
+		status.Kind = aiv1.TemplateKindJobTemplate
+		jobTemplate := &aiv1.JobTemplate{}
+		if err := reader.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: aiJob.Namespace}, jobTemplate); err != nil {
+			return aiv1.JobSpec{}, nil, fmt.Errorf("failed to get JobTemplate %s: %w", ref.Name, err)
+		}
+		template, status.Generation = jobTemplate.Spec, jobTemplate.Generation
+	case aiv1.TemplateKindClusterJobTemplate:
+		clusterTemplate := &aiv1.ClusterJobTemplate{}
+		if err := reader.Get(ctx, client.ObjectKey{Name: ref.Name}, clusterTemplate); err != nil {
+			return aiv1.JobSpec{}, nil, fmt.Errorf("failed to get ClusterJobTemplate %s: %w", ref.Name, err)
+		}
+		template, status.Generation = clusterTemplate.Spec, clusterTemplate.Generation
+	default:
+		return aiv1.JobSpec{}, nil, fmt.Errorf("unknown template kind %q", ref.Kind)
+	}
+
+	spec, err := Merge(template.Template, aiJob.Spec)
+	if err != nil {
+		return aiv1.JobSpec{}, nil, err
+	}
+	return spec, status, nil
+}
+
+// Merge applies the spec of a job as a strategic merge patch to the spec of
+// its template. Templates do not chain, their own reference is ignored.
+func Merge(template, spec aiv1.JobSpec) (aiv1.JobSpec, error) {
+	template.TemplateRef = nil
+	original, err := json.Marshal(template)
+	if err != nil {
+		return aiv1.JobSpec{}, err
+	}
+	patch, err := json.Marshal(spec)
+	if err != nil {
+		return aiv1.JobSpec{}, err
+	}
+	merged, err := strategicpatch.StrategicMergePatch(original, patch, aiv1.JobSpec{})
+	if err != nil {
+		return aiv1.JobSpec{}, fmt.Errorf("failed to merge the template: %w", err)
+	}
+	var result aiv1.JobSpec
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return aiv1.JobSpec{}, err
+	}
+	return result, nil
+}
@@ -0,0 +1,99 @@
+package template
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Job templates", func() {
+	template := aiv1.JobSpec{
+		TemplateRef: &aiv1.TemplateReference{Name: "nested"},
+		Image:       "registry.internal/torchtune:0.5.0",
+		GPUs:        2,
+		NodeSelector: map[string]string{
+			"team": "research",
+		},
+		Tolerations: []corev1.Toleration{
+			{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists},
+		},
+		Postprocess: []aiv1.PostprocessStep{
+			{Name: "merge", Type: aiv1.PostprocessMerge},
+			{Name: "gguf", Type: aiv1.PostprocessConvertGGUF, Scheme: "q4_k_m"},
+		},
+	}
+
+	It("should keep the fields of the template the job does not set", func() {
+		spec, err := Merge(template, aiv1.JobSpec{
+			TemplateRef: &aiv1.TemplateReference{Name: "research"},
+			Model:       "Qwen/Qwen2.5-1.5B-Instruct",
+			GPUs:        4,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.TemplateRef.Name).To(Equal("research"))
+		Expect(spec.Image).To(Equal("registry.internal/torchtune:0.5.0"))
+		Expect(spec.Model).To(Equal("Qwen/Qwen2.5-1.5B-Instruct"))
+		Expect(spec.GPUs).To(Equal(int32(4)))
+		Expect(spec.NodeSelector).To(HaveKeyWithValue("team", "research"))
+		Expect(spec.Tolerations).To(HaveLen(1))
+	})
+
+	It("should merge the postprocess steps by name", func() {
+		spec, err := Merge(template, aiv1.JobSpec{
+			Postprocess: []aiv1.PostprocessStep{
+				{Name: "gguf", Type: aiv1.PostprocessConvertGGUF, Scheme: "q8_0"},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.Postprocess).To(HaveLen(2))
+		Expect(spec.Postprocess[0].Name).To(Equal("merge"))
+		Expect(spec.Postprocess[1].Scheme).To(Equal("q8_0"))
+	})
+
+	It("should not chain templates", func() {
+		spec, err := Merge(template, aiv1.JobSpec{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.TemplateRef).To(BeNil())
+	})
+
+	It("should resolve the template of the job namespace or of the cluster", func() {
+		scheme := runtime.NewScheme()
+		Expect(aiv1.AddToScheme(scheme)).To(Succeed())
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&aiv1.JobTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "research", Namespace: "team-a", Generation: 3},
+				Spec:       aiv1.JobTemplateSpec{Template: aiv1.JobSpec{Image: "registry.internal/team-a:1"}},
+			},
+			&aiv1.ClusterJobTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "research", Generation: 1},
+				Spec:       aiv1.JobTemplateSpec{Template: aiv1.JobSpec{Image: "registry.internal/shared:1"}},
+			},
+		).Build()
+
+		aiJob := aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "team-a"},
+			Spec:       aiv1.JobSpec{TemplateRef: &aiv1.TemplateReference{Name: "research"}},
+		}
+		spec, status, err := Resolve(context.Background(), reader, aiJob)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.Image).To(Equal("registry.internal/team-a:1"))
+		Expect(*status).To(Equal(aiv1.TemplateStatus{Kind: aiv1.TemplateKindJobTemplate, Name: "research", Generation: 3}))
+
+		aiJob.Spec.TemplateRef.Kind = aiv1.TemplateKindClusterJobTemplate
+		spec, _, err = Resolve(context.Background(), reader, aiJob)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec.Image).To(Equal("registry.internal/shared:1"))
+
+		aiJob.Namespace = "team-b"
+		aiJob.Spec.TemplateRef.Kind = aiv1.TemplateKindJobTemplate
+		_, _, err = Resolve(context.Background(), reader, aiJob)
+		Expect(err).To(MatchError(ContainSubstring("failed to get JobTemplate research")))
+	})
+})
@@ -0,0 +1,242 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package tracking talks to experiment tracking servers on behalf of AI Jobs.
+package tracking
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// Error code returned by MLflow for missing experiments
+	mlflowResourceDoesNotExist = "RESOURCE_DOES_NOT_EXIST"
+)
+
+// MLflowClient creates runs through the MLflow REST API.
+type MLflowClient struct {
+	// Base URL of the tracking server
+	TrackingURI string
+
+	// Bearer token, takes precedence over basic authentication
+	Token string
+
+	// Basic authentication credentials
+	Username string
+	Password string
+
+	// HTTP client used for the requests, defaults to one with a timeout
+	HTTPClient *http.Client
+}
+
+// Run is a run created on the tracking server.
+type Run struct {
+	ID           string
+	ExperimentID string
+	URL          string
+}
+
+// mlflowError is the error body returned by MLflow
+type mlflowError struct {
+	ErrorCode string `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func (e *mlflowError) Error() string {
+	return fmt.Sprintf("%s: %s", e.ErrorCode, e.Message)
+}
+
+// CreateRun creates a run in the named experiment, creating the experiment
+// when it does not exist yet. When uniqueTag is set, the run of the
+// experiment carrying that tag with its value in tags is returned instead of
+// creating another one, so that creating the run can be retried.
+func (c *MLflowClient) CreateRun(ctx context.Context, experimentName, runName string, tags map[string]string, uniqueTag string) (*Run, error) {
+	experimentID, err := c.experimentID(ctx, experimentName)
+	if err != nil {
+		return nil, err
+	}
+
+	if uniqueTag != "" {
+		run, err := c.findRun(ctx, experimentID, uniqueTag, tags[uniqueTag])
+		if err != nil || run != nil {
+			return run, err
+		}
+	}
+
+	type tag struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	request := struct {
+		ExperimentID string `json:"experiment_id"`
+		RunName      string `json:"run_name"`
+		StartTime    int64  `json:"start_time"`
+		Tags         []tag  `json:"tags"`
+	}{
+		ExperimentID: experimentID,
+		RunName:      runName,
+		StartTime:    time.Now().UnixMilli(),
+	}
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		request.Tags = append(request.Tags, tag{Key: key, Value: tags[key]})
+	}
+
+	var response struct {
+		Run struct {
+			Info struct {
+				RunID        string `json:"run_id"`
+				ExperimentID string `json:"experiment_id"`
+			} `json:"info"`
+		} `json:"run"`
+	}
+	if err := c.do(ctx, http.MethodPost, "runs/create", nil, request, &response); err != nil {
+		return nil, fmt.Errorf("failed to create run: %w", err)
+	}
+
+	info := response.Run.Info
+	return c.run(info.ExperimentID, info.RunID), nil
+}
+
+// findRun returns the run of the experiment whose tag has the value, nil when
+// there is none
+func (c *MLflowClient) findRun(ctx context.Context, experimentID, tag, value string) (*Run, error) {
+	request := struct {
+		ExperimentIDs []string `json:"experiment_ids"`
+		Filter        string   `json:"filter"`
+		MaxResults    int      `json:"max_results"`
+	}{
+		ExperimentIDs: []string{experimentID},
+		Filter:        fmt.Sprintf("tags.`%s` = '%s'", tag, strings.ReplaceAll(value, "'", "")),
+		MaxResults:    1,
+	}
+	var response struct {
+		Runs []struct {
+			Info struct {
+				RunID        string `json:"run_id"`
+				ExperimentID string `json:"experiment_id"`
+			} `json:"info"`
+		} `json:"runs"`
+	}
+	if err := c.do(ctx, http.MethodPost, "runs/search", nil, request, &response); err != nil {
+		return nil, fmt.Errorf("failed to search runs: %w", err)
+	}
+	if len(response.Runs) == 0 {
+		return nil, nil
+	}
+	info := response.Runs[0].Info
+	return c.run(info.ExperimentID, info.RunID), nil
+}
+
+// run returns the run with its URL in the user interface of the server
+func (c *MLflowClient) run(experimentID, runID string) *Run {
+	return &Run{
+		ID:           runID,
+		ExperimentID: experimentID,
+		URL:          fmt.Sprintf("%s/#/experiments/%s/runs/%s", strings.TrimSuffix(c.TrackingURI, "/"), experimentID, runID),
+	}
+}
+
+// experimentID returns the ID of the named experiment, creating it when needed
+func (c *MLflowClient) experimentID(ctx context.Context, name string) (string, error) {
+	var found struct {
+		Experiment struct {
+			ExperimentID string `json:"experiment_id"`
+		} `json:"experiment"`
+	}
+	err := c.do(ctx, http.MethodGet, "experiments/get-by-name", url.Values{"experiment_name": {name}}, nil, &found)
+	if err == nil {
+		return found.Experiment.ExperimentID, nil
+	}
+	var apiErr *mlflowError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode != mlflowResourceDoesNotExist {
+		return "", fmt.Errorf("failed to get experiment %s: %w", name, err)
+	}
+
+	var created struct {
+		ExperimentID string `json:"experiment_id"`
+	}
+	request := map[string]string{"name": name}
+	if err := c.do(ctx, http.MethodPost, "experiments/create", nil, request, &created); err != nil {
+		return "", fmt.Errorf("failed to create experiment %s: %w", name, err)
+	}
+	return created.ExperimentID, nil
+}
+
+// do sends a request to the MLflow REST API and decodes the response
+func (c *MLflowClient) do(ctx context.Context, method, endpoint string, query url.Values, body, out any) error {
+	u := strings.TrimSuffix(c.TrackingURI, "/") + "/api/2.0/mlflow/" + endpoint
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.Token != "":
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	case c.Username != "":
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := &mlflowError{}
+		if json.Unmarshal(data, apiErr) == nil && apiErr.ErrorCode != "" {
+			return apiErr
+		}
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	return json.Unmarshal(data, out)
+}
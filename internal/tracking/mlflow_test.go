@@ -0,0 +1,147 @@
+package tracking
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeMLflow is a minimal MLflow tracking server keeping experiments and runs in memory
+type fakeMLflow struct {
+	mu          sync.Mutex
+	experiments map[string]string
+	runs        map[string]map[string]string
+	auth        []string
+}
+
+func newFakeMLflow() (*fakeMLflow, *httptest.Server) {
+	fake := &fakeMLflow{
+		experiments: map[string]string{"Default": "0"},
+		runs:        map[string]map[string]string{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/2.0/mlflow/experiments/get-by-name", func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		fake.auth = append(fake.auth, r.Header.Get("Authorization"))
+		id, ok := fake.experiments[r.URL.Query().Get("experiment_name")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_code":"RESOURCE_DOES_NOT_EXIST","message":"not found"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"experiment": map[string]string{"experiment_id": id}})
+	})
+	mux.HandleFunc("POST /api/2.0/mlflow/experiments/create", func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		var request struct {
+			Name string `json:"name"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		id := strconv.Itoa(len(fake.experiments))
+		fake.experiments[request.Name] = id
+		_ = json.NewEncoder(w).Encode(map[string]string{"experiment_id": id})
+	})
+	mux.HandleFunc("POST /api/2.0/mlflow/runs/create", func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		var request struct {
+			ExperimentID string `json:"experiment_id"`
+			Tags         []struct {
+				Key   string `json:"key"`
+				Value string `json:"value"`
+			} `json:"tags"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		id := "run-" + strconv.Itoa(len(fake.runs))
+		fake.runs[id] = map[string]string{}
+		for _, tag := range request.Tags {
+			fake.runs[id][tag.Key] = tag.Value
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"run": map[string]any{"info": map[string]string{"run_id": id, "experiment_id": request.ExperimentID}},
+		})
+	})
+	mux.HandleFunc("POST /api/2.0/mlflow/runs/search", func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		var request struct {
+			Filter string `json:"filter"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&request)
+		// Filters look like tags.`key` = 'value'
+		key, value, _ := strings.Cut(strings.TrimPrefix(request.Filter, "tags.`"), "` = '")
+		value = strings.TrimSuffix(value, "'")
+		runs := []any{}
+		for id, tags := range fake.runs {
+			if tags[key] == value {
+				runs = append(runs, map[string]any{"info": map[string]string{"run_id": id, "experiment_id": "0"}})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"runs": runs})
+	})
+	return fake, httptest.NewServer(mux)
+}
+
+var _ = Describe("MLflow client", func() {
+	var (
+		fake   *fakeMLflow
+		server *httptest.Server
+	)
+
+	BeforeEach(func() {
+		fake, server = newFakeMLflow()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("should create the experiment and a tagged run", func() {
+		client := &MLflowClient{TrackingURI: server.URL + "/", Token: "secret"}
+		run, err := client.CreateRun(context.Background(), "team-a", "finetune", map[string]string{
+			"ai.re-cinq.com/job": "finetune",
+		}, "")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fake.experiments).To(HaveKeyWithValue("team-a", "1"))
+		Expect(run.ExperimentID).To(Equal("1"))
+		Expect(fake.runs).To(HaveKeyWithValue(run.ID, HaveKeyWithValue("ai.re-cinq.com/job", "finetune")))
+		Expect(run.URL).To(Equal(server.URL + "/#/experiments/1/runs/" + run.ID))
+		Expect(fake.auth).To(ConsistOf("Bearer secret"))
+	})
+
+	It("should reuse an existing experiment", func() {
+		client := &MLflowClient{TrackingURI: server.URL}
+		run, err := client.CreateRun(context.Background(), "Default", "finetune", nil, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(run.ExperimentID).To(Equal("0"))
+		Expect(fake.experiments).To(HaveLen(1))
+	})
+
+	It("should reuse the run carrying the unique tag", func() {
+		client := &MLflowClient{TrackingURI: server.URL}
+		tags := map[string]string{"ai.re-cinq.com/uid": "1234"}
+		first, err := client.CreateRun(context.Background(), "Default", "finetune", tags, "ai.re-cinq.com/uid")
+		Expect(err).NotTo(HaveOccurred())
+		second, err := client.CreateRun(context.Background(), "Default", "finetune", tags, "ai.re-cinq.com/uid")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second).To(Equal(first))
+		Expect(fake.runs).To(HaveLen(1))
+	})
+
+	It("should return server errors", func() {
+		server.Close()
+		client := &MLflowClient{TrackingURI: server.URL}
+		_, err := client.CreateRun(context.Background(), "Default", "finetune", nil, "")
+		Expect(err).To(HaveOccurred())
+	})
+})
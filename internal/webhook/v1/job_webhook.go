@@ -0,0 +1,125 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+	"github.com/re-cinq/ai-operator/internal/config"
+	"github.com/re-cinq/ai-operator/internal/policy"
+	"github.com/re-cinq/ai-operator/internal/template"
+)
+
+// log is for logging in this package.
+var joblog = logf.Log.WithName("job-resource")
+
+// SetupJobWebhookWithManager registers the webhook for Job in the manager.
+func SetupJobWebhookWithManager(mgr ctrl.Manager, operatorConfig *config.Watcher) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&aiv1.Job{}).
+		WithValidator(&JobCustomValidator{Client: mgr.GetClient(), Config: operatorConfig}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-ai-re-cinq-com-v1-job,mutating=false,failurePolicy=fail,sideEffects=None,groups=ai.re-cinq.com,resources=jobs,verbs=create;update,versions=v1,name=vjob-v1.kb.io,admissionReviewVersions=v1
+// +kubebuilder:rbac:groups=ai.re-cinq.com,resources=aijobpolicies;clusteraijobpolicies,verbs=get;list;watch
+
+// JobCustomValidator checks the AI Jobs against the AIJobPolicies when they
+// are created or updated.
+type JobCustomValidator struct {
+	Client client.Reader
+
+	// Cluster-wide defaults of the jobs, none when nil
+	Config *config.Watcher
+}
+
+var _ webhook.CustomValidator = &JobCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Job.
+func (v *JobCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	aiJob, ok := obj.(*aiv1.Job)
+	if !ok {
+		return nil, fmt.Errorf("expected a Job object but got %T", obj)
+	}
+	joblog.Info("Validation for Job upon creation", "name", aiJob.GetName())
+
+	return v.validate(ctx, aiJob)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Job.
+func (v *JobCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	aiJob, ok := newObj.(*aiv1.Job)
+	if !ok {
+		return nil, fmt.Errorf("expected a Job object for the newObj but got %T", newObj)
+	}
+	oldJob, ok := oldObj.(*aiv1.Job)
+	if !ok {
+		return nil, fmt.Errorf("expected a Job object for the oldObj but got %T", oldObj)
+	}
+	joblog.Info("Validation for Job upon update", "name", aiJob.GetName())
+
+	// Jobs being deleted and changes of the finalizers or annotations only
+	// must not be blocked by policies created after the job
+	if !aiJob.DeletionTimestamp.IsZero() ||
+		(equality.Semantic.DeepEqual(oldJob.Spec, aiJob.Spec) && maps.Equal(oldJob.Labels, aiJob.Labels)) {
+		return nil, nil
+	}
+	return v.validate(ctx, aiJob)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Job.
+func (v *JobCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks the spec the job will run with, merged over its template
+// and defaulted like the controller does
+func (v *JobCustomValidator) validate(ctx context.Context, aiJob *aiv1.Job) (admission.Warnings, error) {
+	var warnings admission.Warnings
+
+	effective := aiJob.DeepCopy()
+	spec, _, err := template.Resolve(ctx, v.Client, *aiJob)
+	if err != nil {
+		// The template may be created after the job, which the controller
+		// checks against the policies until it records the merged spec
+		warnings = append(warnings, err.Error())
+	} else {
+		effective.Spec = spec
+	}
+	operatorConfig := v.Config.Get()
+	operatorConfig.ApplyDefaults(&effective.Spec)
+	// Invalid specs are reported by the controller, only the defaults matter here
+	_ = effective.Spec.Validate()
+
+	// Roles granting more than the operator allows are never created
+	if err := operatorConfig.CheckServiceAccountRules(effective.Spec); err != nil {
+		return warnings, err
+	}
+
+	policyWarnings, err := policy.Evaluate(ctx, v.Client, *effective)
+	return append(warnings, policyWarnings...), err
+}
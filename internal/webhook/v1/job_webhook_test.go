@@ -0,0 +1,89 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/re-cinq/ai-operator/api/v1"
+)
+
+var _ = Describe("Job Webhook", func() {
+	var (
+		ctx       context.Context
+		validator JobCustomValidator
+		aiJob     *aiv1.Job
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme := runtime.NewScheme()
+		Expect(aiv1.AddToScheme(scheme)).To(Succeed())
+		validator = JobCustomValidator{
+			Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&aiv1.ClusterAIJobPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: "registry"},
+					Spec:       aiv1.AIJobPolicySpec{AllowedImages: []string{"registry.internal/"}},
+				},
+				&aiv1.JobTemplate{
+					ObjectMeta: metav1.ObjectMeta{Name: "research", Namespace: "default"},
+					Spec:       aiv1.JobTemplateSpec{Template: aiv1.JobSpec{Image: "registry.internal/torchtune:0.5.0"}},
+				},
+			).Build(),
+		}
+		aiJob = &aiv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "default"},
+			Spec:       aiv1.JobSpec{HuggingFaceSecret: "token"},
+		}
+	})
+
+	It("should deny the default images that are not allowed", func() {
+		_, err := validator.ValidateCreate(ctx, aiJob)
+		Expect(err).To(MatchError(ContainSubstring("image silentehrec/torchtune:latest is not allowed")))
+	})
+
+	It("should check the spec merged over its template", func() {
+		aiJob.Spec.TemplateRef = &aiv1.TemplateReference{Name: "research"}
+		_, err := validator.ValidateCreate(ctx, aiJob)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should warn when the template does not exist yet", func() {
+		aiJob.Spec.TemplateRef = &aiv1.TemplateReference{Name: "missing"}
+		aiJob.Spec.Image = "registry.internal/torchtune:0.5.0"
+		warnings, err := validator.ValidateCreate(ctx, aiJob)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(warnings).To(ContainElement(ContainSubstring("failed to get JobTemplate missing")))
+	})
+
+	It("should only check updates of the spec or labels", func() {
+		updated := aiJob.DeepCopy()
+		updated.Finalizers = []string{"job.ai.re-cinq.com/finalizer"}
+		_, err := validator.ValidateUpdate(ctx, aiJob, updated)
+		Expect(err).NotTo(HaveOccurred())
+
+		updated.Spec.GPUs = 2
+		_, err = validator.ValidateUpdate(ctx, aiJob, updated)
+		Expect(err).To(HaveOccurred())
+	})
+})